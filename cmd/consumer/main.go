@@ -0,0 +1,120 @@
+// consumer читает события подписок из RabbitMQ (очередь CONSUMER_QUEUE) и передаёт их обработчикам.
+// Неудачная обработка повторяется с задержками CONSUMER_RETRY_DELAYS, затем сообщение уходит в <queue>.dlq.
+//
+//	go run ./cmd/consumer
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/config"
+	"github.com/iokiris/efm-subscription-api/internal/consumer"
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+)
+
+// Границы задержки между попытками переподключения к брокеру
+const (
+	reconnectMin = time.Second
+	reconnectMax = 30 * time.Second
+)
+
+// dedupCleanupInterval период удаления старых отметок обработанных событий
+const dedupCleanupInterval = time.Hour
+
+func main() {
+	logger.InitGlobal()
+	defer func(L *zap.Logger) {
+		_ = L.Sync()
+	}(logger.L)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, _ := config.Load()
+
+	dbPool, err := repo.NewPostgresPool(ctx, cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	if err != nil {
+		logger.L.Fatal("DB Pool error:", zap.Error(err))
+	}
+	defer dbPool.Close()
+	processed := repo.NewProcessedEventRepo(dbPool)
+
+	go cleanupProcessed(ctx, processed, cfg.ConsumerDedupRetention)
+
+	rabbitCfg := &infra.RabbitConfig{
+		User:     cfg.RabbitUser,
+		Password: cfg.RabbitPass,
+		Host:     cfg.RabbitHost,
+		Port:     cfg.RabbitPort,
+	}
+
+	// после разрыва соединения потребитель запускается заново на новом канале
+	backoff := reconnectMin
+	for ctx.Err() == nil {
+		ch, err := infra.DialRabbitChannel(rabbitCfg)
+		if err == nil {
+			backoff = reconnectMin
+			c := consumer.New(ch, processed, cfg.ConsumerQueue, cfg.ConsumerRetryDelays, cfg.ConsumerPrefetch)
+			registerHandlers(c)
+			err = c.Run(ctx)
+			_ = ch.Close()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		logger.L.Error("consumer.run.failed", zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, reconnectMax)
+	}
+	logger.L.Info("shutdown.ok")
+}
+
+// registerHandlers обработчики событий. Пока события только журналируются;
+// проекции и уведомления подключаются здесь же через c.Handle.
+func registerHandlers(c *consumer.Consumer) {
+	for _, e := range events.All() {
+		c.Handle(e, logEvent)
+	}
+}
+
+func logEvent(_ context.Context, env *events.Envelope) error {
+	logger.L.Info("consumer.event.received",
+		zap.String("event_id", env.ID),
+		zap.String("type", env.Type),
+		zap.String("subject", env.Subject),
+		zap.Time("time", env.Time))
+	return nil
+}
+
+// cleanupProcessed периодически удаляет отметки старше retention
+func cleanupProcessed(ctx context.Context, r repo.ProcessedEventRepoInterface, retention time.Duration) {
+	ticker := time.NewTicker(dedupCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := r.DeleteBefore(ctx, time.Now().Add(-retention))
+		if err != nil {
+			logger.L.Error("consumer.dedup.cleanup_failed", zap.Error(err))
+			continue
+		}
+		logger.L.Info("consumer.dedup.cleanup_ok", zap.Int64("deleted", n))
+	}
+}
@@ -0,0 +1,42 @@
+// event-schemas выгружает JSON Schema событий подписок (internal/events) в каталог, по файлу на тип.
+//
+//	go run ./cmd/event-schemas -out docs/events
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	out := flag.String("out", "docs/events", "каталог для схем")
+	flag.Parse()
+
+	logger.InitGlobal()
+	defer func(L *zap.Logger) {
+		_ = L.Sync()
+	}(logger.L)
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		logger.L.Fatal("events.schema.mkdir_failed", zap.String("dir", *out), zap.Error(err))
+	}
+
+	for typ, schema := range events.Schemas() {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			logger.L.Fatal("events.schema.marshal_failed", zap.String("type", typ), zap.Error(err))
+		}
+		path := filepath.Join(*out, typ+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			logger.L.Fatal("events.schema.write_failed", zap.String("file", path), zap.Error(err))
+		}
+	}
+	logger.L.Info("events.schema.ok", zap.String("dir", *out))
+}
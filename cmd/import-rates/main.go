@@ -0,0 +1,79 @@
+// import-rates загружает курсы валют из CSV (date,base,quote,rate) в таблицу exchange_rates.
+//
+//	go run ./cmd/import-rates -file rates.csv
+//	cat rates.csv | go run ./cmd/import-rates
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/iokiris/efm-subscription-api/internal/config"
+	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	file := flag.String("file", "-", "CSV файл с курсами, - — stdin")
+	flag.Parse()
+
+	logger.InitGlobal()
+	defer func(L *zap.Logger) {
+		_ = L.Sync()
+	}(logger.L)
+
+	ctx := context.Background()
+	cfg, _ := config.Load()
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			logger.L.Fatal("rates.import.open_failed", zap.String("file", *file), zap.Error(err))
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rates, err := service.ParseRatesCSV(in)
+	if err != nil {
+		logger.L.Fatal("rates.import.parse_failed", zap.Error(err))
+	}
+
+	dbPool, err := repo.NewPostgresPool(ctx, cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	if err != nil {
+		logger.L.Fatal("DB Pool error:", zap.Error(err))
+	}
+	defer dbPool.Close()
+
+	// Redis нужен только для сброса кеша сумм; без него суммы обновятся по истечении CACHE_TTL
+	var cache service.RedisInterface
+	rcli, err := infra.NewRedis(ctx, infra.RedisConfig{
+		Addr:         cfg.RedisAddr,
+		Password:     cfg.RedisPassword,
+		DB:           cfg.RedisDB,
+		PoolSize:     cfg.RedisPoolSize,
+		MinIdleConns: cfg.RedisMinIdleConns,
+		DialTimeout:  cfg.RedisDialTimeout,
+		ReadTimeout:  cfg.RedisReadTimeout,
+		WriteTimeout: cfg.RedisWriteTimeout,
+		PoolTimeout:  cfg.RedisPoolTimeout,
+	})
+	if err != nil {
+		logger.L.Warn("rates.import.redis_unavailable", zap.Error(err))
+	} else {
+		defer rcli.Close()
+		cache = rcli
+	}
+
+	svc := service.NewExchangeRateService(repo.NewExchangeRateRepo(dbPool), cache)
+	if err := svc.Upsert(ctx, rates); err != nil {
+		logger.L.Fatal("rates.import.failed", zap.Error(err))
+	}
+}
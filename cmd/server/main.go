@@ -5,15 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/iokiris/efm-subscription-api/internal/authz"
 	"github.com/iokiris/efm-subscription-api/internal/config"
 	"github.com/iokiris/efm-subscription-api/internal/handler"
 	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/leader"
 	"github.com/iokiris/efm-subscription-api/internal/logger"
 	"github.com/iokiris/efm-subscription-api/internal/middleware"
+	"github.com/iokiris/efm-subscription-api/internal/outbox"
 	"github.com/iokiris/efm-subscription-api/internal/repo"
 	"github.com/iokiris/efm-subscription-api/internal/service"
+	"github.com/iokiris/efm-subscription-api/internal/stream"
+	"github.com/iokiris/efm-subscription-api/internal/webhook"
+	"github.com/iokiris/efm-subscription-api/internal/worker"
 
 	_ "github.com/iokiris/efm-subscription-api/docs"
 
@@ -45,22 +55,9 @@ func main() {
 	if err != nil {
 		logger.L.Fatal("DB Pool error:", zap.Error(err))
 	}
+	defer dbPool.Close()
 	subRepo := repo.NewSubscriptionRepo(dbPool)
-
-	// RABBITMQ
-	conn, ch, err := infra.NewRabbitMQ(
-		&infra.RabbitConfig{
-			User:     cfg.RabbitUser,
-			Password: cfg.RabbitPass,
-			Host:     cfg.RabbitHost,
-			Port:     cfg.RabbitPort,
-		})
-	if err != nil {
-		logger.L.Fatal("rabbit connection error", zap.Error(err))
-	}
-	publisher := service.NewRabbitPublisher(ch, 100)
-	defer conn.Close()
-	defer ch.Close()
+	outboxRepo := repo.NewOutboxRepo(dbPool)
 
 	// REDIS
 	rcli, err := infra.NewRedis(ctx, infra.RedisConfig{
@@ -98,11 +95,72 @@ func main() {
 		logger.L.Info("Jaeger tracing enabled", zap.String("endpoint", cfg.JaegerEndpoint))
 	}
 
+	// EVENTS
+	publisher, closeBroker := newPublisher(ctx, cfg, metrics)
+
 	// SUBSCRIPTION SERVICE
-	subService := service.NewSubscriptionService(subRepo, rcli, publisher, cfg.CacheTTL)
+	subService := service.NewSubscriptionService(subRepo, rcli, outboxRepo, cfg.CacheTTL)
 	if metrics != nil {
 		subService.SetMetrics(metrics)
 	}
+	webhookRepo := repo.NewWebhookRepo(dbPool)
+	subService.SetWebhooks(webhookRepo)
+	eventLogRepo := repo.NewEventLogRepo(dbPool)
+	subService.SetEventLog(eventLogRepo)
+	streamHub := stream.NewHub(cfg.StreamHistory, cfg.StreamClientBuffer)
+	subService.SetStream(streamHub)
+
+	// фоновые горутины останавливаются отдельно от ctx, после HTTP-сервера
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+
+	// OUTBOX RELAY
+	relay := outbox.NewRelay(outboxRepo, publisher, cfg.OutboxPollInterval, cfg.OutboxBatchSize)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		relay.Run(bgCtx)
+	}()
+
+	// WEBHOOKS
+	dispatcher := webhook.NewDispatcher(webhookRepo, webhook.NewClient(cfg.WebhookTimeout),
+		cfg.WebhookPollInterval, cfg.WebhookBatchSize, cfg.WebhookMaxAttempts, cfg.WebhookDisableAfter)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		dispatcher.Run(bgCtx)
+	}()
+
+	// EVENT REPLAY: прерывается вместе с остальными фоновыми горутинами
+	replayer := service.NewEventReplayService(bgCtx, eventLogRepo, publisher)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		<-bgCtx.Done()
+		replayer.Wait()
+	}()
+
+	// WORKER
+	if cfg.WorkerEnabled {
+		runner := worker.NewRunner(cfg.WorkerTick, repo.NewJobRunRepo(dbPool),
+			worker.NewExpiryJob(subService),
+			worker.NewRenewalDueJob(subService),
+			worker.NewTrialEndingJob(subService, time.Duration(cfg.TrialNoticeDays)*24*time.Hour),
+			worker.NewOutboxCleanupJob(outboxRepo, cfg.OutboxRetention),
+		)
+		// задачи выполняет только реплика, владеющая advisory lock
+		elector := leader.New(dbPool, "subscription-worker", cfg.LeaderHeartbeat)
+		if metrics != nil {
+			runner.SetMetrics(metrics)
+			elector.SetMetrics(metrics)
+		}
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			elector.Run(bgCtx, runner.Run)
+		}()
+	}
 
 	// GIN ROUTES INIT
 	r := gin.New()
@@ -131,11 +189,53 @@ func main() {
 	url := ginSwagger.URL("/swagger/doc.json")
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
+	// AUTH
+	// AUTH_ENABLED=false → маршруты доступны без авторизации, user_id передаётся клиентом,
+	// маршруты /admin, /api-keys и /webhooks не регистрируются
+	var authMws []gin.HandlerFunc
+	apiKeyService := service.NewAPIKeyService(repo.NewAPIKeyRepo(dbPool))
+	if cfg.AuthEnabled {
+		jwtCfg := middleware.JWTConfig{
+			Secret:    []byte(cfg.JWTSecret),
+			Issuer:    cfg.JWTIssuer,
+			Audience:  cfg.JWTAudience,
+			UserClaim: cfg.JWTUserClaim,
+			RoleClaim: cfg.JWTRoleClaim,
+			Leeway:    cfg.JWTLeeway,
+		}
+		if cfg.JWTJWKS != "" {
+			keySet, err := middleware.LoadKeySet(ctx, cfg.JWTJWKS, cfg.JWTJWKSRefresh)
+			if err != nil {
+				logger.L.Fatal("failed to load JWKS", zap.Error(err))
+			}
+			jwtCfg.KeySet = keySet
+		}
+		// без JWT_SECRET/JWT_JWKS принимаются только API ключи
+		var jwtMw gin.HandlerFunc
+		if len(jwtCfg.Secret) > 0 || jwtCfg.KeySet != nil {
+			jwtMw = middleware.JWTMiddleware(jwtCfg)
+		} else {
+			logger.L.Warn("JWT_SECRET and JWT_JWKS are empty, only API keys are accepted")
+		}
+		authMws = append(authMws,
+			middleware.APIKeyMiddleware(apiKeyService, jwtMw),
+			authz.Middleware(authz.DefaultPolicy),
+		)
+		logger.L.Info("auth enabled")
+	}
+
 	// subscriptions
-	// NOTE:
-	// authRequired = true → требует авторизацию с middleware.JWTMiddleware() (т.к. заглушка, будет выдавать 401)
 	h := handler.NewSubscriptionHandler(subService)
-	h.RegisterRoutes(r, false)
+	h.RegisterRoutes(r, authMws...)
+	handler.NewStreamHandler(streamHub, cfg.StreamHeartbeat).RegisterRoutes(r, authMws...)
+
+	if cfg.AuthEnabled {
+		handler.NewAdminHandler(subService).RegisterRoutes(r, authMws...)
+		handler.NewExchangeRateHandler(service.NewExchangeRateService(repo.NewExchangeRateRepo(dbPool), rcli)).RegisterRoutes(r, authMws...)
+		handler.NewAPIKeyHandler(apiKeyService).RegisterRoutes(r, authMws...)
+		handler.NewWebhookHandler(service.NewWebhookService(webhookRepo)).RegisterRoutes(r, authMws...)
+		handler.NewEventReplayHandler(replayer).RegisterRoutes(r, authMws...)
+	}
 
 	// HTTP
 	srv := &http.Server{
@@ -144,13 +244,86 @@ func main() {
 		ReadTimeout:  cfg.HTTPReadTimeout,
 		WriteTimeout: cfg.HTTPWriteTimeout,
 	}
+	// Shutdown не прерывает активные запросы: потоки SSE закрываются сами
+	srv.RegisterOnShutdown(streamHub.Close)
 
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		logger.L.Fatal("server failed", zap.Error(err))
-	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.L.Fatal("server failed", zap.Error(err))
+		}
+	}()
 
-	// graceful shutdown
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	<-sigCtx.Done()
+	logger.L.Info("shutdown.start")
+
+	// graceful shutdown: HTTP -> relay, webhooks, replay и воркер -> досылка публикатора -> брокер.
+	// Redis, Postgres и трейсер закрываются отложенными вызовами выше.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancelShutdown()
-	_ = srv.Shutdown(shutdownCtx)
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.L.Error("shutdown.http.failed", zap.Error(err))
+	}
+
+	stopBackground()
+	stopped := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		logger.L.Warn("shutdown.background.timeout")
+	}
+
+	// недоставленные события остаются в outbox и будут отправлены после перезапуска
+	dropped := publisher.Close(shutdownCtx)
+	closeBroker()
+	logger.L.Info("shutdown.ok", zap.Int("dropped", dropped))
+}
+
+// newPublisher создаёт публикатор событий для cfg.EventsBackend.
+// closeBroker закрывает соединение с брокером; вызывается после publisher.Close.
+func newPublisher(ctx context.Context, cfg *config.Config, metrics *infra.Metrics) (publisher service.Publisher, closeBroker func()) {
+	switch cfg.EventsBackend {
+	case "rabbitmq":
+		rabbit, err := infra.NewRabbitMQ(
+			&infra.RabbitConfig{
+				User:     cfg.RabbitUser,
+				Password: cfg.RabbitPass,
+				Host:     cfg.RabbitHost,
+				Port:     cfg.RabbitPort,
+			})
+		if err != nil {
+			logger.L.Fatal("rabbit connection error", zap.Error(err))
+		}
+		pub := service.NewRabbitPublisher(rabbit, 100, cfg.PublishTimeout)
+		if metrics != nil {
+			pub.SetMetrics(metrics)
+		}
+		return pub, rabbit.Close
+
+	case "nats":
+		nc, js, err := infra.NewJetStream(ctx, cfg.NATSURL, cfg.NATSStream, "subscriptions")
+		if err != nil {
+			logger.L.Fatal("nats connection error", zap.Error(err))
+		}
+		return service.NewNATSPublisher(js, cfg.PublishTimeout), nc.Close
+
+	case "kafka":
+		w := infra.NewKafkaWriter(cfg.KafkaBrokers)
+		return service.NewKafkaPublisher(w, cfg.PublishTimeout), func() { _ = w.Close() }
+
+	case "memory":
+		return service.NewMemoryBus(), func() {}
+
+	case "noop":
+		return service.NewNoopPublisher(), func() {}
+	}
+
+	logger.L.Fatal("unknown EVENTS_BACKEND", zap.String("backend", cfg.EventsBackend))
+	return nil, nil
 }
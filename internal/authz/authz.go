@@ -0,0 +1,128 @@
+package authz
+
+import (
+	"net/http"
+
+	"github.com/iokiris/efm-subscription-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission право на группу операций
+type Permission string
+
+const (
+	SubscriptionsRead  Permission = "subscriptions:read"
+	SubscriptionsWrite Permission = "subscriptions:write"
+	AdminRead          Permission = "admin:read"
+	AdminWrite         Permission = "admin:write"
+	APIKeysManage      Permission = "api_keys:manage"
+	WebhooksManage     Permission = "webhooks:manage"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// rolePermissions права ролей. Пользователь без роли считается RoleUser.
+var rolePermissions = map[string][]Permission{
+	RoleUser:  {SubscriptionsRead, SubscriptionsWrite, APIKeysManage, WebhooksManage},
+	RoleAdmin: {SubscriptionsRead, SubscriptionsWrite, APIKeysManage, WebhooksManage, AdminRead, AdminWrite},
+}
+
+// IsKnown существует ли такое право (для проверки scopes при выпуске API ключей)
+func IsKnown(p Permission) bool {
+	for _, known := range rolePermissions[RoleAdmin] {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy сопоставляет маршрут ("METHOD /full/path" в терминах gin) с требуемым правом
+type Policy map[string]Permission
+
+// DefaultPolicy права для всех маршрутов API
+var DefaultPolicy = Policy{
+	"POST /subscriptions":             SubscriptionsWrite,
+	"PUT /subscriptions/:id":          SubscriptionsWrite,
+	"DELETE /subscriptions/:id":       SubscriptionsWrite,
+	"GET /subscriptions/:id":          SubscriptionsRead,
+	"GET /subscriptions/:id/prices":   SubscriptionsRead,
+	"POST /subscriptions/:id/pause":   SubscriptionsWrite,
+	"POST /subscriptions/:id/resume":  SubscriptionsWrite,
+	"GET /subscriptions":              SubscriptionsRead,
+	"GET /subscriptions/summary":      SubscriptionsRead,
+	"GET /subscriptions/stream":       SubscriptionsRead,
+	"GET /subscriptions/search":       SubscriptionsRead,
+	"GET /subscriptions/autocomplete": SubscriptionsRead,
+
+	"GET /admin/subscriptions":         AdminRead,
+	"GET /admin/subscriptions/summary": AdminRead,
+	"GET /admin/subscriptions/:id":     AdminRead,
+	"POST /admin/subscriptions":        AdminWrite,
+	"PUT /admin/subscriptions/:id":     AdminWrite,
+	"DELETE /admin/subscriptions/:id":  AdminWrite,
+	"GET /admin/exchange-rates":        AdminRead,
+	"PUT /admin/exchange-rates":        AdminWrite,
+	"POST /admin/events/replay":        AdminWrite,
+
+	"POST /api-keys":       APIKeysManage,
+	"GET /api-keys":        APIKeysManage,
+	"DELETE /api-keys/:id": APIKeysManage,
+
+	"POST /webhooks":               WebhooksManage,
+	"GET /webhooks":                WebhooksManage,
+	"GET /webhooks/:id":            WebhooksManage,
+	"PUT /webhooks/:id":            WebhooksManage,
+	"DELETE /webhooks/:id":         WebhooksManage,
+	"GET /webhooks/:id/deliveries": WebhooksManage,
+	"GET /webhooks/:id/deliveries/:delivery_id/attempts": WebhooksManage,
+}
+
+// Permissions возвращает объединение прав для набора ролей
+func Permissions(roles []string) map[Permission]bool {
+	perms := make(map[Permission]bool)
+	if len(roles) == 0 {
+		roles = []string{RoleUser}
+	}
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			perms[p] = true
+		}
+	}
+	return perms
+}
+
+// Granted права текущего запроса: для API ключа — его scopes, иначе — права ролей из токена
+func Granted(c *gin.Context) map[Permission]bool {
+	if v, ok := c.Get(middleware.ContextScopes); ok {
+		scopes, _ := v.([]string)
+		perms := make(map[Permission]bool)
+		for _, s := range scopes {
+			perms[Permission(s)] = true
+		}
+		return perms
+	}
+	return Permissions(c.GetStringSlice(middleware.ContextRoles))
+}
+
+// Middleware проверяет, что у пользователя есть право, требуемое маршрутом.
+// Ставится после middleware аутентификации. Маршрут без записи в политике запрещён.
+func Middleware(p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required, ok := p[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "route is not allowed"})
+			return
+		}
+
+		if !Granted(c)[required] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package authz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iokiris/efm-subscription-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(roles []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserID, "user1")
+		c.Set(middleware.ContextRoles, roles)
+		c.Next()
+	}, Middleware(DefaultPolicy))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/subscriptions", ok)
+	r.DELETE("/subscriptions/:id", ok)
+	r.GET("/admin/subscriptions", ok)
+	r.DELETE("/admin/subscriptions/:id", ok)
+	r.GET("/unlisted", ok)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		roles          []string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"user reads own", nil, "GET", "/subscriptions", http.StatusOK},
+		{"user deletes own", []string{RoleUser}, "DELETE", "/subscriptions/1", http.StatusOK},
+		{"user lists all", nil, "GET", "/admin/subscriptions", http.StatusForbidden},
+		{"user deletes any", []string{RoleUser}, "DELETE", "/admin/subscriptions/1", http.StatusForbidden},
+		{"admin lists all", []string{RoleAdmin}, "GET", "/admin/subscriptions", http.StatusOK},
+		{"admin deletes any", []string{RoleUser, RoleAdmin}, "DELETE", "/admin/subscriptions/1", http.StatusOK},
+		{"unknown role", []string{"guest"}, "GET", "/subscriptions", http.StatusForbidden},
+		{"route without policy", []string{RoleAdmin}, "GET", "/unlisted", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter(tt.roles)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMiddleware_APIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserID, "user1")
+		c.Set(middleware.ContextScopes, []string{string(SubscriptionsRead)})
+		c.Next()
+	}, Middleware(DefaultPolicy))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/subscriptions", ok)
+	r.POST("/subscriptions", ok)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/subscriptions", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/subscriptions", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
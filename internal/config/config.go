@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -35,12 +36,59 @@ type Config struct {
 	RabbitHost string
 	RabbitPort int
 
+	// События: брокер (rabbitmq, nats, kafka, memory, noop) и таймаут подтверждения публикации
+	EventsBackend  string
+	PublishTimeout time.Duration
+	NATSURL        string
+	NATSStream     string
+	KafkaBrokers   []string
+
 	HTTPReadTimeout  time.Duration
 	HTTPWriteTimeout time.Duration
+	ShutdownTimeout  time.Duration
 	WorkerTick       time.Duration
 
+	// Фоновые задачи
+	WorkerEnabled   bool
+	TrialNoticeDays int
+	LeaderHeartbeat time.Duration
+
+	// Outbox
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxRetention    time.Duration
+
+	// Потребитель событий (cmd/consumer)
+	ConsumerQueue          string
+	ConsumerPrefetch       int
+	ConsumerRetryDelays    []time.Duration
+	ConsumerDedupRetention time.Duration
+
+	// Webhooks
+	WebhookPollInterval time.Duration
+	WebhookBatchSize    int
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookDisableAfter int
+
+	// Поток изменений (SSE): период heartbeat, событий в буфере возобновления, очередь соединения
+	StreamHeartbeat    time.Duration
+	StreamHistory      int
+	StreamClientBuffer int
+
 	LogLevel string
 
+	// Авторизация
+	AuthEnabled    bool
+	JWTSecret      string
+	JWTJWKS        string // путь к JWKS файлу или URL
+	JWTJWKSRefresh time.Duration
+	JWTIssuer      string
+	JWTAudience    string
+	JWTUserClaim   string
+	JWTRoleClaim   string
+	JWTLeeway      time.Duration
+
 	// Мониторинг TODO
 	JaegerEndpoint string
 	MetricsEnabled bool
@@ -73,8 +121,27 @@ func Load() (*Config, error) {
 	c.CacheTTL = getEnvAsDuration("CACHE_TTL", 10*time.Minute)
 	c.HTTPReadTimeout = getEnvAsDuration("HTTP_READ_TIMEOUT", 15*time.Second)
 	c.HTTPWriteTimeout = getEnvAsDuration("HTTP_WRITE_TIMEOUT", 15*time.Second)
+	c.ShutdownTimeout = getEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
 	c.WorkerTick = getEnvAsDuration("WORKER_TICK", 5*time.Minute)
 
+	c.WorkerEnabled = getEnvAsBool("WORKER_ENABLED", true)
+	c.TrialNoticeDays = getEnvAsInt("TRIAL_NOTICE_DAYS", 3)
+	c.LeaderHeartbeat = getEnvAsDuration("LEADER_HEARTBEAT", 10*time.Second)
+
+	c.OutboxPollInterval = getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second)
+	c.OutboxBatchSize = getEnvAsInt("OUTBOX_BATCH_SIZE", 100)
+	c.OutboxRetention = getEnvAsDuration("OUTBOX_RETENTION", 7*24*time.Hour)
+
+	c.WebhookPollInterval = getEnvAsDuration("WEBHOOK_POLL_INTERVAL", time.Second)
+	c.WebhookBatchSize = getEnvAsInt("WEBHOOK_BATCH_SIZE", 20)
+	c.WebhookTimeout = getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	c.WebhookMaxAttempts = getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	c.WebhookDisableAfter = getEnvAsInt("WEBHOOK_DISABLE_AFTER", 20)
+
+	c.StreamHeartbeat = getEnvAsDuration("STREAM_HEARTBEAT", 15*time.Second)
+	c.StreamHistory = getEnvAsInt("STREAM_HISTORY", 1000)
+	c.StreamClientBuffer = getEnvAsInt("STREAM_CLIENT_BUFFER", 64)
+
 	c.RedisPassword = getEnv("REDIS_PASSWORD", "")
 	c.RedisDB = getEnvAsInt("REDIS_DB", 0)
 	c.RedisPoolSize = getEnvAsInt("REDIS_POOL_SIZE", 50)
@@ -90,6 +157,29 @@ func Load() (*Config, error) {
 	c.RabbitHost = getEnv("RABBIT_HOST", "rabbitmq")
 	c.RabbitPort = getEnvAsInt("RABBIT_PORT", 5672)
 
+	c.EventsBackend = getEnv("EVENTS_BACKEND", "rabbitmq")
+	// RABBIT_PUBLISH_TIMEOUT — прежнее имя, пока таймаут был только у RabbitMQ
+	c.PublishTimeout = getEnvAsDuration("EVENTS_PUBLISH_TIMEOUT", getEnvAsDuration("RABBIT_PUBLISH_TIMEOUT", 5*time.Second))
+	c.NATSURL = getEnv("NATS_URL", "nats://nats:4222")
+	c.NATSStream = getEnv("NATS_STREAM", "SUBSCRIPTIONS")
+	c.KafkaBrokers = strings.Split(getEnv("KAFKA_BROKERS", "kafka:9092"), ",")
+
+	c.ConsumerQueue = getEnv("CONSUMER_QUEUE", "efm-consumer")
+	c.ConsumerPrefetch = getEnvAsInt("CONSUMER_PREFETCH", 10)
+	c.ConsumerRetryDelays = getEnvAsDurations("CONSUMER_RETRY_DELAYS", []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute})
+	c.ConsumerDedupRetention = getEnvAsDuration("CONSUMER_DEDUP_RETENTION", 7*24*time.Hour)
+
+	// Авторизация
+	c.AuthEnabled = getEnvAsBool("AUTH_ENABLED", false)
+	c.JWTSecret = getEnv("JWT_SECRET", "")
+	c.JWTJWKS = getEnv("JWT_JWKS", "")
+	c.JWTJWKSRefresh = getEnvAsDuration("JWT_JWKS_REFRESH", time.Minute)
+	c.JWTIssuer = getEnv("JWT_ISSUER", "")
+	c.JWTAudience = getEnv("JWT_AUDIENCE", "")
+	c.JWTUserClaim = getEnv("JWT_USER_CLAIM", "sub")
+	c.JWTRoleClaim = getEnv("JWT_ROLE_CLAIM", "role")
+	c.JWTLeeway = getEnvAsDuration("JWT_LEEWAY", 30*time.Second)
+
 	// Мониторинг
 	c.JaegerEndpoint = getEnv("JAEGER_ENDPOINT", "http://jaeger:14268/api/traces")
 	c.MetricsEnabled = getEnvAsBool("METRICS_ENABLED", true)
@@ -127,6 +217,23 @@ func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+// getEnvAsDurations список длительностей через запятую; при ошибке разбора — fallback
+func getEnvAsDurations(key string, fallback []time.Duration) []time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	var out []time.Duration
+	for _, part := range strings.Split(val, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return fallback
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
 func getEnvAsBool(key string, fallback bool) bool {
 	if val := os.Getenv(key); val != "" {
 		if b, err := strconv.ParseBool(val); err == nil {
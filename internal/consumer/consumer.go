@@ -0,0 +1,274 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// publishTimeout ожидание подтверждения при переносе сообщения в очередь повторов или dlq
+const publishTimeout = 5 * time.Second
+
+// lastErrorLimit сколько символов ошибки обработчика сохраняется в заголовке сообщения
+const lastErrorLimit = 512
+
+// ErrConnectionLost брокер закрыл канал доставок (разрыв соединения)
+var ErrConnectionLost = errors.New("consumer connection lost")
+
+// Broker операции брокера, нужные потребителю (infra.RabbitChannel)
+type Broker interface {
+	DeclareExchange(name, kind string) error
+	DeclareQueue(name string, args amqp.Table) error
+	BindQueue(queue, routingKey, exchange string) error
+	Consume(queue string, prefetch int) (<-chan amqp.Delivery, error)
+	Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
+}
+
+// Store отметки обработанных событий (repo.ProcessedEventRepo).
+// Claim внутри InTx: отметка и изменения обработчика фиксируются вместе.
+type Store interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Claim(ctx context.Context, consumer, eventID string) (bool, error)
+}
+
+// Handler обрабатывает событие. Ошибка — повтор с задержкой; Permanent(err) — сразу в dlq.
+// Вызывается внутри транзакции Store: репозитории, получившие ctx, работают в ней.
+type Handler func(ctx context.Context, env *events.Envelope) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку неисправимой: сообщение не повторяется и уходит в dlq
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Consumer читает события из своей очереди и передаёт их обработчикам по типу события.
+// Событие с уже обработанным id подтверждается без вызова обработчика, кроме replay (HeaderReplay).
+// Неудачная обработка повторяется через очереди с задержками Topology.Delays, затем — dlq.
+type Consumer struct {
+	broker   Broker
+	store    Store
+	topology Topology
+	prefetch int
+
+	handlers map[string]Handler // по типу события
+	keys     []string           // ключи маршрутизации обработчиков
+}
+
+// New создаёт потребителя очереди queue; len(delays) — число повторов, prefetch — параллельных обработок
+func New(b Broker, store Store, queue string, delays []time.Duration, prefetch int) *Consumer {
+	return &Consumer{
+		broker:   b,
+		store:    store,
+		topology: Topology{Queue: queue, Delays: delays},
+		prefetch: max(prefetch, 1),
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle регистрирует обработчик событий типа e; очередь привязывается к e.RoutingKey()
+func (c *Consumer) Handle(e events.Event, h Handler) {
+	if _, ok := c.handlers[e.Type()]; !ok {
+		c.keys = append(c.keys, e.RoutingKey())
+	}
+	c.handlers[e.Type()] = h
+}
+
+// Run объявляет топологию и обрабатывает сообщения до отмены ctx (возвращает nil)
+// или разрыва соединения (ErrConnectionLost). Начатые обработки завершаются в обоих случаях.
+func (c *Consumer) Run(ctx context.Context) error {
+	if len(c.handlers) == 0 {
+		return errors.New("consumer: no handlers registered")
+	}
+	if err := c.topology.Declare(c.broker, c.keys); err != nil {
+		return err
+	}
+	deliveries, err := c.broker.Consume(c.topology.Queue, c.prefetch)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", c.topology.Queue, err)
+	}
+
+	logger.L.Info("consumer.started",
+		zap.String("queue", c.topology.Queue),
+		zap.Strings("routing_keys", c.keys),
+		zap.Int("prefetch", c.prefetch))
+
+	var wg sync.WaitGroup
+	var lost sync.Once
+	lostCh := make(chan struct{})
+	for range c.prefetch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-lostCh:
+					return
+				case d, ok := <-deliveries:
+					if !ok {
+						lost.Do(func() { close(lostCh) })
+						return
+					}
+					// обработка не прерывается остановкой: сообщение подтверждается до выхода
+					c.process(context.WithoutCancel(ctx), d)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		logger.L.Info("consumer.stopped", zap.String("queue", c.topology.Queue))
+		return nil
+	}
+	return ErrConnectionLost
+}
+
+// process обрабатывает одно сообщение и подтверждает его, перенеся при необходимости в повтор или dlq
+func (c *Consumer) process(ctx context.Context, d amqp.Delivery) {
+	var env events.Envelope
+	if err := json.Unmarshal(d.Body, &env); err != nil || env.ID == "" || env.Type == "" {
+		c.deadLetter(ctx, d, fmt.Sprintf("malformed event: %v", err))
+		return
+	}
+	h, ok := c.handlers[env.Type]
+	if !ok {
+		c.deadLetter(ctx, d, "no handler for event type "+env.Type)
+		return
+	}
+
+	replay := d.Headers[HeaderReplay] == "true"
+	duplicate := false
+	err := c.store.InTx(env.Context(ctx), func(ctx context.Context) error {
+		fresh, err := c.store.Claim(ctx, c.topology.Queue, env.ID)
+		if err != nil {
+			return err
+		}
+		if !fresh && !replay {
+			duplicate = true
+			return nil
+		}
+		return h(ctx, &env)
+	})
+
+	var permanent permanentError
+	switch {
+	case err == nil:
+		if duplicate {
+			logger.L.Info("consumer.event.duplicate", zap.String("event_id", env.ID), zap.String("type", env.Type))
+		}
+		_ = d.Ack(false)
+	case errors.As(err, &permanent):
+		c.deadLetter(ctx, d, err.Error())
+	default:
+		c.retry(ctx, d, &env, err)
+	}
+}
+
+// retry переносит сообщение в очередь повторов следующей задержки; после последней — в dlq
+func (c *Consumer) retry(ctx context.Context, d amqp.Delivery, env *events.Envelope, cause error) {
+	n := retryCount(d.Headers)
+	if n >= len(c.topology.Delays) {
+		c.deadLetter(ctx, d, fmt.Sprintf("retries exhausted: %v", cause))
+		return
+	}
+
+	delay := c.topology.Delays[n]
+	headers := copyHeaders(d.Headers)
+	headers[HeaderRetryCount] = int32(n + 1)
+	headers[HeaderLastError] = truncate(cause.Error(), lastErrorLimit)
+
+	// default exchange: ключ маршрутизации — имя очереди
+	if err := c.republish(ctx, d, "", c.topology.RetryQueue(delay), headers); err != nil {
+		return
+	}
+	logger.L.Warn("consumer.event.retry",
+		zap.String("event_id", env.ID),
+		zap.String("type", env.Type),
+		zap.Int("attempt", n+1),
+		zap.Duration("delay", delay),
+		zap.Error(cause))
+}
+
+// deadLetter переносит сообщение в dlq с причиной в заголовке
+func (c *Consumer) deadLetter(ctx context.Context, d amqp.Delivery, reason string) {
+	headers := copyHeaders(d.Headers)
+	headers[HeaderDeadLetterReason] = truncate(reason, lastErrorLimit)
+
+	if err := c.republish(ctx, d, DeadLetterExchange, c.topology.Queue, headers); err != nil {
+		return
+	}
+	id, _ := events.Peek(d.Body)
+	logger.L.Error("consumer.event.dead_lettered",
+		zap.String("event_id", id),
+		zap.String("routing_key", d.RoutingKey),
+		zap.String("reason", reason))
+}
+
+// republish публикует копию сообщения и подтверждает оригинал.
+// Если брокер не принял копию, оригинал возвращается в очередь, чтобы не потерять его.
+func (c *Consumer) republish(ctx context.Context, d amqp.Delivery, exchange, routingKey string, headers amqp.Table) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	err := c.broker.Publish(ctx, exchange, routingKey, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		MessageId:    d.MessageId,
+		Timestamp:    d.Timestamp,
+		DeliveryMode: amqp.Persistent,
+		Body:         d.Body,
+	})
+	if err != nil {
+		logger.L.Error("consumer.republish.failed",
+			zap.String("exchange", exchange),
+			zap.String("routing_key", routingKey),
+			zap.Error(err))
+		_ = d.Nack(false, true)
+		return err
+	}
+	_ = d.Ack(false)
+	return nil
+}
+
+// retryCount число уже выполненных повторов из заголовка; тип числа зависит от клиента, записавшего его
+func retryCount(h amqp.Table) int {
+	switch v := h[HeaderRetryCount].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 0
+}
+
+func copyHeaders(h amqp.Table) amqp.Table {
+	out := make(amqp.Table, len(h)+2)
+	for k, v := range h {
+		out[k] = v
+	}
+	return out
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,380 @@
+package consumer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/consumer"
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+type binding struct{ exchange, key string }
+
+// memBroker брокер в памяти с семантикой RabbitMQ, нужной потребителю: маршрутизация по точному ключу,
+// default exchange, x-message-ttl (истекает сразу) и x-dead-letter-*
+type memBroker struct {
+	mu        sync.Mutex
+	exchanges map[string]string
+	queues    map[string]amqp.Table
+	bindings  map[string][]binding // очередь -> привязки
+	stored    map[string][]amqp.Delivery
+	consumers map[string]chan amqp.Delivery
+	routed    []string // очереди, через которые прошли сообщения, по порядку
+	tag       uint64
+	acked     atomic.Int64
+}
+
+func newMemBroker() *memBroker {
+	return &memBroker{
+		exchanges: make(map[string]string),
+		queues:    make(map[string]amqp.Table),
+		bindings:  make(map[string][]binding),
+		stored:    make(map[string][]amqp.Delivery),
+		consumers: make(map[string]chan amqp.Delivery),
+	}
+}
+
+func (b *memBroker) DeclareExchange(name, kind string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.exchanges[name] = kind
+	return nil
+}
+
+func (b *memBroker) DeclareQueue(name string, args amqp.Table) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queues[name] = args
+	return nil
+}
+
+func (b *memBroker) BindQueue(queue, routingKey, exchange string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bindings[queue] = append(b.bindings[queue], binding{exchange, routingKey})
+	return nil
+}
+
+func (b *memBroker) Consume(queue string, _ int) (<-chan amqp.Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan amqp.Delivery, 100)
+	for _, d := range b.stored[queue] {
+		ch <- d
+	}
+	delete(b.stored, queue)
+	b.consumers[queue] = ch
+	return ch, nil
+}
+
+func (b *memBroker) Publish(_ context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.route(exchange, routingKey, msg)
+	return nil
+}
+
+// route доставляет сообщение во все подходящие очереди; вызывается под b.mu
+func (b *memBroker) route(exchange, key string, msg amqp.Publishing) {
+	if exchange == "" {
+		b.enqueue(key, exchange, key, msg)
+		return
+	}
+	for queue, binds := range b.bindings {
+		for _, bind := range binds {
+			if bind.exchange == exchange && bind.key == key {
+				b.enqueue(queue, exchange, key, msg)
+			}
+		}
+	}
+}
+
+func (b *memBroker) enqueue(queue, exchange, key string, msg amqp.Publishing) {
+	b.routed = append(b.routed, queue)
+	args := b.queues[queue]
+	if _, ok := args["x-message-ttl"]; ok {
+		b.route(args["x-dead-letter-exchange"].(string), args["x-dead-letter-routing-key"].(string), msg)
+		return
+	}
+
+	b.tag++
+	d := amqp.Delivery{
+		Acknowledger: b,
+		DeliveryTag:  b.tag,
+		Headers:      maps.Clone(msg.Headers),
+		ContentType:  msg.ContentType,
+		MessageId:    msg.MessageId,
+		Exchange:     exchange,
+		RoutingKey:   key,
+		Body:         msg.Body,
+	}
+	if ch, ok := b.consumers[queue]; ok {
+		ch <- d
+		return
+	}
+	b.stored[queue] = append(b.stored[queue], d)
+}
+
+func (b *memBroker) Ack(uint64, bool) error {
+	b.acked.Add(1)
+	return nil
+}
+
+func (b *memBroker) Nack(uint64, bool, bool) error { return errors.New("unexpected nack") }
+func (b *memBroker) Reject(uint64, bool) error     { return errors.New("unexpected reject") }
+
+func (b *memBroker) publishEvent(t *testing.T, e events.Event, id string) {
+	b.publishWithHeaders(t, e, id, nil)
+}
+
+func (b *memBroker) publishWithHeaders(t *testing.T, e events.Event, id string, headers amqp.Table) {
+	env, err := events.New(context.Background(), e)
+	require.NoError(t, err)
+	env.ID = id
+	body, err := json.Marshal(env)
+	require.NoError(t, err)
+	require.NoError(t, b.Publish(context.Background(), consumer.EventsExchange, e.RoutingKey(), amqp.Publishing{Headers: headers, Body: body}))
+}
+
+func (b *memBroker) deadLettered(queue string) []amqp.Delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]amqp.Delivery(nil), b.stored[queue+".dlq"]...)
+}
+
+func (b *memBroker) routedTo() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.routed...)
+}
+
+// memStore отметки в памяти; InTx откатывает отметки при ошибке
+type memStore struct {
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+func (s *memStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := maps.Clone(s.claimed)
+	if err := fn(ctx); err != nil {
+		s.claimed = snapshot
+		return err
+	}
+	return nil
+}
+
+// Claim вызывается только внутри InTx, под s.mu
+func (s *memStore) Claim(_ context.Context, consumerName, eventID string) (bool, error) {
+	key := consumerName + "/" + eventID
+	if s.claimed[key] {
+		return false, nil
+	}
+	s.claimed[key] = true
+	return true, nil
+}
+
+func startConsumer(t *testing.T, c *consumer.Consumer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
+
+func TestConsumer_Topology(t *testing.T) {
+	b := newMemBroker()
+	c := consumer.New(b, &memStore{claimed: map[string]bool{}}, "billing", []time.Duration{time.Second, time.Minute}, 1)
+	c.Handle(events.SubscriptionCreated{}, func(context.Context, *events.Envelope) error { return nil })
+	c.Handle(events.SubscriptionDeleted{}, func(context.Context, *events.Envelope) error { return nil })
+	startConsumer(t, c)
+
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.consumers["billing"] != nil
+	}, time.Second, 5*time.Millisecond)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.Equal(t, map[string]string{"subscriptions": "topic", "subscriptions.dlx": "direct"}, b.exchanges)
+	assert.ElementsMatch(t, []binding{{"subscriptions", "created"}, {"subscriptions", "deleted"}}, b.bindings["billing"])
+	assert.Equal(t, []binding{{"subscriptions.dlx", "billing"}}, b.bindings["billing.dlq"])
+	assert.Equal(t, amqp.Table{
+		"x-message-ttl":             int64(60000),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": "billing",
+	}, b.queues["billing.retry.1m0s"])
+	assert.Equal(t, "subscriptions.dlx", b.queues["billing"]["x-dead-letter-exchange"])
+}
+
+func TestConsumer_Process(t *testing.T) {
+	sub := events.SubscriptionCreated{Subscription: model.Subscription{ID: 42, UserID: "u1", Service: "Netflix"}}
+	errTemporary := errors.New("db timeout")
+
+	tests := []struct {
+		name string
+		// fail возвращает ошибку для вызова n (с 0)
+		fail       func(n int) error
+		publish    func(t *testing.T, b *memBroker)
+		wantCalls  int
+		wantRouted []string
+		wantDLQ    string // причина в dlq
+	}{
+		{
+			name:       "processed once",
+			publish:    func(t *testing.T, b *memBroker) { b.publishEvent(t, sub, "e1") },
+			wantCalls:  1,
+			wantRouted: []string{"q"},
+		},
+		{
+			name: "duplicate event id skipped",
+			publish: func(t *testing.T, b *memBroker) {
+				b.publishEvent(t, sub, "e1")
+				b.publishEvent(t, sub, "e1")
+			},
+			wantCalls:  1,
+			wantRouted: []string{"q", "q"},
+		},
+		{
+			name: "replayed duplicate processed again",
+			publish: func(t *testing.T, b *memBroker) {
+				b.publishEvent(t, sub, "e1")
+				b.publishWithHeaders(t, sub, "e1", amqp.Table{consumer.HeaderReplay: "true"})
+			},
+			wantCalls:  2,
+			wantRouted: []string{"q", "q"},
+		},
+		{
+			name: "retried with growing delay",
+			fail: func(n int) error {
+				if n < 2 {
+					return errTemporary
+				}
+				return nil
+			},
+			publish:    func(t *testing.T, b *memBroker) { b.publishEvent(t, sub, "e1") },
+			wantCalls:  3,
+			wantRouted: []string{"q", "q.retry.1s", "q", "q.retry.10s", "q"},
+		},
+		{
+			name:       "retries exhausted",
+			fail:       func(int) error { return errTemporary },
+			publish:    func(t *testing.T, b *memBroker) { b.publishEvent(t, sub, "e1") },
+			wantCalls:  4,
+			wantRouted: []string{"q", "q.retry.1s", "q", "q.retry.10s", "q", "q.retry.1m0s", "q", "q.dlq"},
+			wantDLQ:    "retries exhausted: db timeout",
+		},
+		{
+			name:       "permanent error",
+			fail:       func(int) error { return consumer.Permanent(errors.New("unknown currency")) },
+			publish:    func(t *testing.T, b *memBroker) { b.publishEvent(t, sub, "e1") },
+			wantCalls:  1,
+			wantRouted: []string{"q", "q.dlq"},
+			wantDLQ:    "unknown currency",
+		},
+		{
+			name: "malformed body",
+			publish: func(t *testing.T, b *memBroker) {
+				require.NoError(t, b.Publish(context.Background(), "subscriptions", "created", amqp.Publishing{Body: []byte(`not json`)}))
+			},
+			wantRouted: []string{"q", "q.dlq"},
+			wantDLQ:    "malformed event: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMemBroker()
+			delays := []time.Duration{time.Second, 10 * time.Second, time.Minute}
+			c := consumer.New(b, &memStore{claimed: map[string]bool{}}, "q", delays, 1)
+
+			var calls atomic.Int64
+			c.Handle(events.SubscriptionCreated{}, func(ctx context.Context, env *events.Envelope) error {
+				n := int(calls.Add(1)) - 1
+				assert.Equal(t, "42", env.Subject)
+				if tt.fail != nil {
+					return tt.fail(n)
+				}
+				return nil
+			})
+			startConsumer(t, c)
+			require.Eventually(t, func() bool {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				return b.consumers["q"] != nil
+			}, time.Second, 5*time.Millisecond)
+
+			tt.publish(t, b)
+
+			require.Eventually(t, func() bool {
+				return len(b.routedTo()) == len(tt.wantRouted) && int(b.acked.Load()) == countOf(tt.wantRouted, "q")
+			}, time.Second, 5*time.Millisecond, "routed: %v", b.routedTo())
+			assert.Equal(t, tt.wantRouted, b.routedTo())
+			assert.Equal(t, int64(tt.wantCalls), calls.Load())
+
+			dlq := b.deadLettered("q")
+			if tt.wantDLQ == "" {
+				assert.Empty(t, dlq)
+				return
+			}
+			require.Len(t, dlq, 1)
+			assert.Equal(t, tt.wantDLQ, dlq[0].Headers[consumer.HeaderDeadLetterReason])
+		})
+	}
+}
+
+func TestConsumer_RetryHeaders(t *testing.T) {
+	b := newMemBroker()
+	c := consumer.New(b, &memStore{claimed: map[string]bool{}}, "q", []time.Duration{time.Second, time.Second * 2}, 1)
+
+	var calls atomic.Int64
+	c.Handle(events.SubscriptionDeleted{}, func(ctx context.Context, env *events.Envelope) error {
+		calls.Add(1)
+		return errors.New("boom")
+	})
+	startConsumer(t, c)
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.consumers["q"] != nil
+	}, time.Second, 5*time.Millisecond)
+
+	b.publishEvent(t, events.SubscriptionDeleted{ID: 1, UserID: "u1"}, "e2")
+	require.Eventually(t, func() bool { return len(b.deadLettered("q")) == 1 }, time.Second, 5*time.Millisecond)
+
+	dlq := b.deadLettered("q")[0]
+	assert.Equal(t, int32(2), dlq.Headers[consumer.HeaderRetryCount])
+	assert.Equal(t, "boom", dlq.Headers[consumer.HeaderLastError])
+	assert.Equal(t, int64(3), calls.Load())
+}
+
+func countOf(items []string, v string) int {
+	n := 0
+	for _, it := range items {
+		if it == v {
+			n++
+		}
+	}
+	return n
+}
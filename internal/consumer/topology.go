@@ -0,0 +1,89 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// EventsExchange topic exchange, в который сервис публикует события подписок
+	EventsExchange = "subscriptions"
+	// DeadLetterExchange direct exchange сообщений, которые не удалось обработать; ключ — имя очереди
+	DeadLetterExchange = "subscriptions.dlx"
+)
+
+// Заголовки сообщений, переносимых в очереди повторов и dead-letter
+const (
+	HeaderRetryCount       = "x-retry-count"
+	HeaderLastError        = "x-last-error"
+	HeaderDeadLetterReason = "x-dead-letter-reason"
+)
+
+// HeaderReplay заголовок событий, повторно опубликованных из журнала ("true").
+// Такие события обрабатываются, даже если их id уже обработан.
+const HeaderReplay = "replay"
+
+// Topology имена очередей потребителя queue:
+//
+//	queue           — основная, привязана к EventsExchange по ключам обработчиков;
+//	queue.retry.<d> — по одной на задержку: сообщение лежит d (x-message-ttl)
+//	                  и через default exchange возвращается в queue;
+//	queue.dlq       — сообщения, исчерпавшие повторы или с неисправимой ошибкой.
+type Topology struct {
+	Queue  string
+	Delays []time.Duration
+}
+
+// RetryQueue очередь повторов с задержкой d
+func (t Topology) RetryQueue(d time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", t.Queue, d)
+}
+
+// DeadLetterQueue очередь сообщений, которые не удалось обработать
+func (t Topology) DeadLetterQueue() string {
+	return t.Queue + ".dlq"
+}
+
+// Declare объявляет exchanges и очереди и привязывает queue к routingKeys. Повторный вызов безопасен,
+// но смена аргументов существующей очереди (задержки под тем же именем) брокером отклоняется.
+func (t Topology) Declare(b Broker, routingKeys []string) error {
+	if err := b.DeclareExchange(EventsExchange, amqp.ExchangeTopic); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", EventsExchange, err)
+	}
+	if err := b.DeclareExchange(DeadLetterExchange, amqp.ExchangeDirect); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", DeadLetterExchange, err)
+	}
+
+	if err := b.DeclareQueue(t.DeadLetterQueue(), nil); err != nil {
+		return fmt.Errorf("declare queue %s: %w", t.DeadLetterQueue(), err)
+	}
+	if err := b.BindQueue(t.DeadLetterQueue(), t.Queue, DeadLetterExchange); err != nil {
+		return fmt.Errorf("bind queue %s: %w", t.DeadLetterQueue(), err)
+	}
+
+	// отклонённые брокером сообщения (reject без requeue) тоже попадают в dlq
+	if err := b.DeclareQueue(t.Queue, amqp.Table{
+		"x-dead-letter-exchange":    DeadLetterExchange,
+		"x-dead-letter-routing-key": t.Queue,
+	}); err != nil {
+		return fmt.Errorf("declare queue %s: %w", t.Queue, err)
+	}
+	for _, key := range routingKeys {
+		if err := b.BindQueue(t.Queue, key, EventsExchange); err != nil {
+			return fmt.Errorf("bind queue %s to %s: %w", t.Queue, key, err)
+		}
+	}
+
+	for _, d := range t.Delays {
+		if err := b.DeclareQueue(t.RetryQueue(d), amqp.Table{
+			"x-message-ttl":             d.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": t.Queue,
+		}); err != nil {
+			return fmt.Errorf("declare queue %s: %w", t.RetryQueue(d), err)
+		}
+	}
+	return nil
+}
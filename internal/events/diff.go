@@ -0,0 +1,50 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+)
+
+// FieldChange значения поля до и после изменения в том виде, в каком поле сериализуется в JSON
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// diffIgnored поля, которые меняются при любом обновлении и не считаются изменением
+var diffIgnored = map[string]bool{"updated_at": true}
+
+var jsonNull = json.RawMessage("null")
+
+// Diff поля подписки, различающиеся в JSON-представлении prev и next; ключ — имя поля в JSON.
+// Поле, отсутствующее с одной стороны (omitempty), имеет там значение null.
+func Diff(prev, next model.Subscription) map[string]FieldChange {
+	before, after := jsonFields(prev), jsonFields(next)
+
+	changes := make(map[string]FieldChange)
+	for name, to := range after {
+		from, ok := before[name]
+		if !ok {
+			from = jsonNull
+		}
+		if !diffIgnored[name] && !bytes.Equal(from, to) {
+			changes[name] = FieldChange{From: from, To: to}
+		}
+	}
+	for name, from := range before {
+		if _, ok := after[name]; !ok && !diffIgnored[name] {
+			changes[name] = FieldChange{From: from, To: jsonNull}
+		}
+	}
+	return changes
+}
+
+func jsonFields(s model.Subscription) map[string]json.RawMessage {
+	// ошибка невозможна: все поля подписки сериализуются
+	data, _ := json.Marshal(s)
+	fields := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
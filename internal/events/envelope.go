@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// SpecVersion версия спецификации CloudEvents
+	SpecVersion = "1.0"
+	// Source атрибут source всех событий сервиса
+	Source = "/efm-subscription-api"
+	// ContentType тип сообщения в брокере (structured mode CloudEvents)
+	ContentType = "application/cloudevents+json"
+)
+
+// Envelope конверт CloudEvents 1.0 в JSON-формате.
+// TraceParent и TraceState — расширение Distributed Tracing: W3C trace context операции,
+// породившей событие; потребитель продолжает трейс с него.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	TraceState      string          `json:"tracestate,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// New оборачивает событие в конверт: новый id, текущее время и trace context из ctx
+func New(ctx context.Context, e Event) (*Envelope, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", e.Type(), err)
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              newID(),
+		Source:          Source,
+		Type:            e.Type(),
+		Subject:         e.Subject(),
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     carrier.Get("traceparent"),
+		TraceState:      carrier.Get("tracestate"),
+		Data:            data,
+	}, nil
+}
+
+// Context возвращает ctx с trace context события: span потребителя станет дочерним
+func (e *Envelope) Context(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{"traceparent": e.TraceParent, "tracestate": e.TraceState}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// Peek читает id и subject конверта, не декодируя data: для дедупликации и ключей партиционирования.
+// Для тела, не являющегося конвертом, возвращает пустые строки.
+func Peek(body []byte) (id, subject string) {
+	var head struct {
+		ID      string `json:"id"`
+		Subject string `json:"subject"`
+	}
+	_ = json.Unmarshal(body, &head)
+	return head.ID, head.Subject
+}
+
+// newID случайный UUID v4
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,147 @@
+// Package events описывает события подписок, публикуемые в брокер.
+// Каждое событие — типизированная структура с версией в типе (subscription.created.v1);
+// в брокер оно уходит в конверте CloudEvents 1.0 (см. Envelope).
+// Несовместимое изменение структуры — новый тип с суффиксом .v2, старый публикуется до отказа потребителей.
+package events
+
+import (
+	"strconv"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+)
+
+// Типы событий (атрибут type CloudEvents)
+const (
+	TypeSubscriptionCreated = "subscription.created.v1"
+	TypeSubscriptionUpdated = "subscription.updated.v1"
+	TypeSubscriptionDeleted = "subscription.deleted.v1"
+	TypeSubscriptionPaused  = "subscription.paused.v1"
+	TypeSubscriptionResumed = "subscription.resumed.v1"
+	TypeTrialEnding         = "subscription.trial_ending.v1"
+	TypeSubscriptionExpired = "subscription.expired.v1"
+	TypeRenewalDue          = "subscription.renewal_due.v1"
+)
+
+// Event событие подписки: данные (data) с типом, ключом маршрутизации и subject
+type Event interface {
+	// Type тип CloudEvents с версией схемы
+	Type() string
+	// RoutingKey ключ маршрутизации в exchange subscriptions
+	RoutingKey() string
+	// Subject идентификатор подписки, к которой относится событие
+	Subject() string
+	// Owner пользователь — владелец подписки (получатель webhooks)
+	Owner() string
+}
+
+// SubscriptionCreated подписка создана
+type SubscriptionCreated struct {
+	Subscription model.Subscription `json:"subscription"`
+}
+
+func (SubscriptionCreated) Type() string       { return TypeSubscriptionCreated }
+func (SubscriptionCreated) RoutingKey() string { return "created" }
+func (e SubscriptionCreated) Subject() string  { return subject(e.Subscription.ID) }
+func (e SubscriptionCreated) Owner() string    { return e.Subscription.UserID }
+
+// SubscriptionUpdated подписка изменена: Subscription — состояние после изменения,
+// Previous — до него, Changes — изменённые поля (см. Diff)
+type SubscriptionUpdated struct {
+	Subscription model.Subscription     `json:"subscription"`
+	Previous     model.Subscription     `json:"previous"`
+	Changes      map[string]FieldChange `json:"changes"`
+}
+
+// NewSubscriptionUpdated событие обновления prev -> next
+func NewSubscriptionUpdated(prev, next model.Subscription) SubscriptionUpdated {
+	return SubscriptionUpdated{Subscription: next, Previous: prev, Changes: Diff(prev, next)}
+}
+
+func (SubscriptionUpdated) Type() string       { return TypeSubscriptionUpdated }
+func (SubscriptionUpdated) RoutingKey() string { return "updated" }
+func (e SubscriptionUpdated) Subject() string  { return subject(e.Subscription.ID) }
+func (e SubscriptionUpdated) Owner() string    { return e.Subscription.UserID }
+
+// SubscriptionDeleted подписка удалена
+type SubscriptionDeleted struct {
+	ID     int64  `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (SubscriptionDeleted) Type() string       { return TypeSubscriptionDeleted }
+func (SubscriptionDeleted) RoutingKey() string { return "deleted" }
+func (e SubscriptionDeleted) Subject() string  { return subject(e.ID) }
+func (e SubscriptionDeleted) Owner() string    { return e.UserID }
+
+// SubscriptionPaused подписка приостановлена
+type SubscriptionPaused struct {
+	ID     int64                   `json:"id"`
+	UserID string                  `json:"user_id"`
+	Pause  model.SubscriptionPause `json:"pause"`
+}
+
+func (SubscriptionPaused) Type() string       { return TypeSubscriptionPaused }
+func (SubscriptionPaused) RoutingKey() string { return "paused" }
+func (e SubscriptionPaused) Subject() string  { return subject(e.ID) }
+func (e SubscriptionPaused) Owner() string    { return e.UserID }
+
+// SubscriptionResumed пауза подписки завершена
+type SubscriptionResumed struct {
+	ID     int64                   `json:"id"`
+	UserID string                  `json:"user_id"`
+	Pause  model.SubscriptionPause `json:"pause"`
+}
+
+func (SubscriptionResumed) Type() string       { return TypeSubscriptionResumed }
+func (SubscriptionResumed) RoutingKey() string { return "resumed" }
+func (e SubscriptionResumed) Subject() string  { return subject(e.ID) }
+func (e SubscriptionResumed) Owner() string    { return e.UserID }
+
+// TrialEnding пробный период скоро закончится
+type TrialEnding struct {
+	Subscription model.Subscription `json:"subscription"`
+}
+
+func (TrialEnding) Type() string       { return TypeTrialEnding }
+func (TrialEnding) RoutingKey() string { return "trial_ending" }
+func (e TrialEnding) Subject() string  { return subject(e.Subscription.ID) }
+func (e TrialEnding) Owner() string    { return e.Subscription.UserID }
+
+// SubscriptionExpired подписка закончилась (прошёл месяц end_date)
+type SubscriptionExpired struct {
+	Subscription model.Subscription `json:"subscription"`
+}
+
+func (SubscriptionExpired) Type() string       { return TypeSubscriptionExpired }
+func (SubscriptionExpired) RoutingKey() string { return "expired" }
+func (e SubscriptionExpired) Subject() string  { return subject(e.Subscription.ID) }
+func (e SubscriptionExpired) Owner() string    { return e.Subscription.UserID }
+
+// RenewalDue наступило очередное списание
+type RenewalDue struct {
+	Subscription model.Subscription `json:"subscription"`
+	DueOn        model.Date         `json:"due_on"`
+}
+
+func (RenewalDue) Type() string       { return TypeRenewalDue }
+func (RenewalDue) RoutingKey() string { return "renewal_due" }
+func (e RenewalDue) Subject() string  { return subject(e.Subscription.ID) }
+func (e RenewalDue) Owner() string    { return e.Subscription.UserID }
+
+// All нулевые значения всех событий: для генерации схем и документации
+func All() []Event {
+	return []Event{
+		SubscriptionCreated{},
+		SubscriptionUpdated{},
+		SubscriptionDeleted{},
+		SubscriptionPaused{},
+		SubscriptionResumed{},
+		TrialEnding{},
+		SubscriptionExpired{},
+		RenewalDue{},
+	}
+}
+
+func subject(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
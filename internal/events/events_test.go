@@ -0,0 +1,151 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		event           events.Event
+		wantType        string
+		wantSubject     string
+		wantTraceParent string
+	}{
+		{
+			name:            "created with trace context",
+			ctx:             traced,
+			event:           events.SubscriptionCreated{Subscription: model.Subscription{ID: 42, Service: "Netflix"}},
+			wantType:        "subscription.created.v1",
+			wantSubject:     "42",
+			wantTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "deleted without trace",
+			ctx:         context.Background(),
+			event:       events.SubscriptionDeleted{ID: 7, UserID: "u1"},
+			wantType:    "subscription.deleted.v1",
+			wantSubject: "7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := events.New(tt.ctx, tt.event)
+			require.NoError(t, err)
+
+			assert.Equal(t, "1.0", env.SpecVersion)
+			assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, env.ID)
+			assert.Equal(t, events.Source, env.Source)
+			assert.Equal(t, tt.wantType, env.Type)
+			assert.Equal(t, tt.wantSubject, env.Subject)
+			assert.WithinDuration(t, time.Now(), env.Time, time.Second)
+			assert.Equal(t, tt.wantTraceParent, env.TraceParent)
+
+			data, err := json.Marshal(tt.event)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(data), string(env.Data))
+
+			if tt.wantTraceParent != "" {
+				sc := trace.SpanContextFromContext(env.Context(context.Background()))
+				assert.Equal(t, traceID, sc.TraceID())
+				assert.True(t, sc.IsRemote())
+			}
+		})
+	}
+}
+
+// Схемы в docs/events должны совпадать с кодом: go run ./cmd/event-schemas -out docs/events
+func TestSchemas_UpToDate(t *testing.T) {
+	for typ, schema := range events.Schemas() {
+		t.Run(typ, func(t *testing.T) {
+			want, err := json.Marshal(schema)
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(filepath.Join("..", "..", "docs", "events", typ+".json"))
+			require.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
+
+func TestSchema_Data(t *testing.T) {
+	schema := events.Schema(events.RenewalDue{})
+	data := schema["properties"].(map[string]any)["data"].(map[string]any)
+	props := data["properties"].(map[string]any)
+
+	assert.Equal(t, []string{"subscription", "due_on"}, data["required"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "date"}, props["due_on"])
+
+	sub := props["subscription"].(map[string]any)
+	subProps := sub["properties"].(map[string]any)
+	assert.NotContains(t, subProps, "Paused")
+	assert.NotContains(t, sub["required"], "end_date")
+	assert.Equal(t, []string{"week", "month", "quarter", "year"}, subProps["billing_period"].(map[string]any)["enum"])
+	assert.Equal(t, map[string]any{"type": "integer", "minimum": float64(1)}, subProps["billing_interval"])
+}
+
+func TestDiff(t *testing.T) {
+	start := model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+	end := model.MonthYear(time.Date(2099, 12, 1, 0, 0, 0, 0, time.UTC))
+	base := model.Subscription{
+		ID: 1, Service: "Netflix", Price: 299, Currency: "RUB", BillingPeriod: model.BillingMonth,
+		BillingInterval: 1, UserID: "u1", StartDate: start, UpdatedAt: time.Now(),
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *model.Subscription)
+		want   map[string]events.FieldChange
+	}{
+		{
+			name:   "no changes, updated_at ignored",
+			change: func(s *model.Subscription) { s.UpdatedAt = s.UpdatedAt.Add(time.Hour) },
+			want:   map[string]events.FieldChange{},
+		},
+		{
+			name:   "price change includes monthly cost",
+			change: func(s *model.Subscription) { s.Price = 399 },
+			want: map[string]events.FieldChange{
+				"price":        {From: json.RawMessage(`299`), To: json.RawMessage(`399`)},
+				"monthly_cost": {From: json.RawMessage(`299`), To: json.RawMessage(`399`)},
+			},
+		},
+		{
+			name:   "omitted field becomes null",
+			change: func(s *model.Subscription) { s.EndDate = &end },
+			want: map[string]events.FieldChange{
+				"end_date": {From: json.RawMessage(`null`), To: json.RawMessage(`"12-2099"`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			tt.change(&next)
+			assert.Equal(t, tt.want, events.Diff(base, next))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+)
+
+// SchemaDialect версия JSON Schema
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema JSON Schema конверта события e: атрибуты CloudEvents и data, построенная по структуре события.
+// Используются json-теги и swag-теги enums/minimum модели, как в Swagger.
+func Schema(e Event) map[string]any {
+	str := func() map[string]any { return map[string]any{"type": "string"} }
+
+	return map[string]any{
+		"$schema": SchemaDialect,
+		"$id":     e.Type(),
+		"title":   e.Type(),
+		"type":    "object",
+		"properties": map[string]any{
+			"specversion":     map[string]any{"const": SpecVersion},
+			"id":              str(),
+			"source":          map[string]any{"const": Source},
+			"type":            map[string]any{"const": e.Type()},
+			"subject":         str(),
+			"time":            map[string]any{"type": "string", "format": "date-time"},
+			"datacontenttype": map[string]any{"const": "application/json"},
+			"traceparent":     str(),
+			"tracestate":      str(),
+			"data":            schemaOf(reflect.TypeOf(e), ""),
+		},
+		"required": []string{"specversion", "id", "source", "type", "time", "datacontenttype", "data"},
+	}
+}
+
+// Schemas схемы всех событий по типу
+func Schemas() map[string]map[string]any {
+	out := make(map[string]map[string]any)
+	for _, e := range All() {
+		out[e.Type()] = Schema(e)
+	}
+	return out
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	dateType      = reflect.TypeOf(model.Date{})
+	monthYearType = reflect.TypeOf(model.MonthYear{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf схема типа t; tag — теги поля структуры, в которой он объявлен
+func schemaOf(t reflect.Type, tag reflect.StructTag) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case dateType:
+		return map[string]any{"type": "string", "format": "date"}
+	case monthYearType:
+		return map[string]any{"type": "string", "pattern": `^(0[1-9]|1[0-2])-\d{4}$`}
+	case rawType:
+		// произвольное JSON-значение
+		return map[string]any{}
+	}
+
+	var s map[string]any
+	switch t.Kind() {
+	case reflect.Struct:
+		return objectSchema(t)
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), "")}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), "")}
+	case reflect.String:
+		s = map[string]any{"type": "string"}
+		if enums := tag.Get("enums"); enums != "" {
+			s["enum"] = strings.Split(enums, ",")
+		}
+	case reflect.Bool:
+		s = map[string]any{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		s = map[string]any{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = map[string]any{"type": "integer"}
+	default:
+		return map[string]any{}
+	}
+
+	if m, err := strconv.ParseFloat(tag.Get("minimum"), 64); err == nil {
+		s["minimum"] = m
+	}
+	return s
+}
+
+// objectSchema схема структуры: поля по json-тегам, обязательны поля без omitempty
+func objectSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = schemaOf(f.Type, f.Tag)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler операции администратора над подписками любых пользователей
+type AdminHandler struct {
+	svc service.SubscriptionServiceInterface
+}
+
+func NewAdminHandler(svc service.SubscriptionServiceInterface) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+// RegisterRoutes регистрирует маршруты /admin.
+// Доступ ограничивается через mws (authz.Middleware с правами admin:*).
+func (h *AdminHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/admin/subscriptions", mws...)
+	{
+		g.GET("", h.List)
+		g.GET("/summary", h.Summary)
+		g.GET(":id", h.Get)
+		g.POST("", h.Create)
+		g.PUT(":id", h.Update)
+		g.DELETE(":id", h.Delete)
+	}
+}
+
+// List godoc
+// @Summary		Список подписок всех пользователей
+// @Description	Возвращает подписки с необязательными фильтрами по пользователю и сервису
+// @Tags			admin
+// @Produce		json
+// @Param			user_id			query	string	false	"ID пользователя (UUID)"
+// @Param			service_name	query	string	false	"Имя сервиса"
+// @Param			trial			query	string	false	"Пробный период: active, converted или none"
+// @Success		200		{array}		model.Subscription
+// @Failure		400		{object}	map[string]string
+// @Failure		403		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/admin/subscriptions [get]
+func (h *AdminHandler) List(c *gin.Context) {
+	trial, ok := trialQuery(c)
+	if !ok {
+		return
+	}
+	f := model.SubscriptionFilter{
+		UserID:  c.Query("user_id"),
+		Service: c.Query("service_name"),
+		Trial:   trial,
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	subs, err := h.svc.ListAll(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// Summary godoc
+// @Summary		Сумма по подпискам всех пользователей
+// @Description	Как /subscriptions/summary, но user_id необязателен: без него считается по всем пользователям
+// @Tags			admin
+// @Produce		json
+// @Param			user_id			query	string	false	"ID пользователя"
+// @Param			service_name	query	string	false	"Имя сервиса"
+// @Param			from			query	string	false	"Дата начала (MM-YYYY)"
+// @Param			to			query	string	false	"Дата конца (MM-YYYY)"
+// @Param			group_by		query	string	false	"Разбивка: service, month или service,month"
+// @Param			currency		query	string	false	"Валюта суммы (ISO 4217), по умолчанию RUB"
+// @Success		200		{object}	model.Summary
+// @Failure		400		{object}	map[string]string
+// @Failure		403		{object}	map[string]string
+// @Router		/admin/subscriptions/summary [get]
+func (h *AdminHandler) Summary(c *gin.Context) {
+	q := summaryQuery(c)
+	q.UserID = c.Query("user_id")
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	summary, err := h.svc.GetSummary(ctx, q)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// Get godoc
+// @Summary		Получить любую подписку
+// @Tags			admin
+// @Produce		json
+// @Param			id	path	int	true	"ID подписки"
+// @Success		200	{object}	model.Subscription
+// @Failure		400	{object}	map[string]string
+// @Failure		404	{object}	map[string]string
+// @Router		/admin/subscriptions/{id} [get]
+func (h *AdminHandler) Get(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	sub, err := h.svc.Get(ctx, id, "")
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// Create godoc
+// @Summary		Создать подписку от имени пользователя
+// @Description	user_id в теле обязателен
+// @Tags			admin
+// @Accept		json
+// @Produce		json
+// @Param			body	body		model.Subscription	true	"Данные подписки"
+// @Success		201		{object}	model.Subscription
+// @Failure		400		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/admin/subscriptions [post]
+func (h *AdminHandler) Create(c *gin.Context) {
+	var in model.Subscription
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := h.svc.Create(ctx, &in); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, in)
+}
+
+// Update godoc
+// @Summary		Обновить любую подписку
+// @Tags			admin
+// @Accept		json
+// @Produce		json
+// @Param			id		path		int	true	"ID подписки"
+// @Param			body	body		model.Subscription	true	"Данные подписки"
+// @Success		200		{object}	model.Subscription
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Router		/admin/subscriptions/{id} [put]
+func (h *AdminHandler) Update(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in model.Subscription
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	in.ID = id
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := h.svc.Update(ctx, &in, ""); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, in)
+}
+
+// Delete godoc
+// @Summary		Удалить любую подписку
+// @Tags			admin
+// @Produce		json
+// @Param			id	path	int	true	"ID подписки"
+// @Success		204		""
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Router		/admin/subscriptions/{id} [delete]
+func (h *AdminHandler) Delete(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := h.svc.Delete(ctx, id, ""); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAdminTestRouter(mockSvc *MockSubscriptionService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	h := &AdminHandler{svc: mockSvc}
+	h.RegisterRoutes(r)
+
+	return r
+}
+
+func TestAdminHandler(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           map[string]interface{}
+		mockSetup      func(*MockSubscriptionService)
+		expectedStatus int
+	}{
+		{
+			name:   "list with filters",
+			method: "GET",
+			url:    "/admin/subscriptions?user_id=" + userID + "&service_name=Netflix",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("ListAll", mock.Anything, model.SubscriptionFilter{UserID: userID, Service: "Netflix"}).
+					Return([]model.Subscription{{ID: 1, UserID: userID, Service: "Netflix"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "list by trial state",
+			method: "GET",
+			url:    "/admin/subscriptions?trial=active",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("ListAll", mock.Anything, model.SubscriptionFilter{Trial: model.TrialActive}).
+					Return([]model.Subscription{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list with invalid trial",
+			method:         "GET",
+			url:            "/admin/subscriptions?trial=soon",
+			mockSetup:      func(_ *MockSubscriptionService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "summary across users",
+			method: "GET",
+			url:    "/admin/subscriptions/summary",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{}).Return(&model.Summary{Total: 5000}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "create on behalf",
+			method: "POST",
+			url:    "/admin/subscriptions",
+			body: map[string]interface{}{
+				"service_name": "Netflix",
+				"price":        799,
+				"user_id":      userID,
+				"start_date":   "07-2025",
+			},
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Create", mock.Anything, mock.MatchedBy(func(sub *model.Subscription) bool {
+					return sub.UserID == userID
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "create without user_id",
+			method: "POST",
+			url:    "/admin/subscriptions",
+			body: map[string]interface{}{
+				"service_name": "Netflix",
+				"price":        799,
+				"start_date":   "07-2025",
+			},
+			mockSetup:      func(_ *MockSubscriptionService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "delete any",
+			method: "DELETE",
+			url:    "/admin/subscriptions/7",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Delete", mock.Anything, int64(7), "").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockSubscriptionService)
+			tt.mockSetup(mockSvc)
+
+			router := setupAdminTestRouter(mockSvc)
+
+			var req *http.Request
+			if tt.body != nil {
+				body, _ := json.Marshal(tt.body)
+				req = httptest.NewRequest(tt.method, tt.url, bytes.NewBuffer(body))
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				req = httptest.NewRequest(tt.method, tt.url, nil)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
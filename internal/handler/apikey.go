@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/authz"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler выпуск и отзыв API ключей текущего пользователя
+type APIKeyHandler struct {
+	svc service.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(svc service.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+// CreateAPIKeyRequest тело запроса на выпуск ключа
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse ответ с секретом ключа. Секрет показывается один раз.
+type CreateAPIKeyResponse struct {
+	Key    string       `json:"key"`
+	APIKey model.APIKey `json:"api_key"`
+}
+
+// RegisterRoutes регистрирует маршруты /api-keys. Требует авторизации через mws.
+func (h *APIKeyHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/api-keys", mws...)
+	{
+		g.POST("", h.Create)
+		g.GET("", h.List)
+		g.DELETE(":id", h.Revoke)
+	}
+}
+
+// Create godoc
+// @Summary		Выпустить API ключ
+// @Description	Создаёт ключ для машинного клиента. Секрет возвращается только в этом ответе.
+// @Description	scopes не могут превышать права вызывающего; по умолчанию — subscriptions:read и subscriptions:write.
+// @Tags			api-keys
+// @Accept		json
+// @Produce		json
+// @Param			body	body		CreateAPIKeyRequest	true	"Параметры ключа"
+// @Success		201		{object}	CreateAPIKeyResponse
+// @Failure		400		{object}	map[string]string
+// @Failure		403		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var in CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(in.Scopes) == 0 {
+		in.Scopes = []string{string(authz.SubscriptionsRead), string(authz.SubscriptionsWrite)}
+	}
+	if in.ExpiresAt != nil && in.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	granted := authz.Granted(c)
+	for _, scope := range in.Scopes {
+		if !authz.IsKnown(authz.Permission(scope)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope: " + scope})
+			return
+		}
+		if !granted[authz.Permission(scope)] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "scope exceeds caller permissions: " + scope})
+			return
+		}
+	}
+
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	key, secret, err := h.svc.Create(ctx, userID, in.Name, in.Scopes, in.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: secret, APIKey: *key})
+}
+
+// List godoc
+// @Summary		Список API ключей
+// @Description	Возвращает ключи текущего пользователя без секретов
+// @Tags			api-keys
+// @Produce		json
+// @Success		200		{array}		model.APIKey
+// @Failure		500		{object}	map[string]string
+// @Router		/api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	keys, err := h.svc.List(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Revoke godoc
+// @Summary		Отозвать API ключ
+// @Tags			api-keys
+// @Produce		json
+// @Param			id	path	int	true	"ID ключа"
+// @Success		204		""
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := h.svc.Revoke(ctx, id, userID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
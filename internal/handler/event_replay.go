@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventReplayHandler повторная публикация событий из журнала (для администраторов)
+type EventReplayHandler struct {
+	svc service.EventReplayServiceInterface
+}
+
+func NewEventReplayHandler(svc service.EventReplayServiceInterface) *EventReplayHandler {
+	return &EventReplayHandler{svc: svc}
+}
+
+// ReplayEventsRequest тело запроса на replay. Пустые фильтры не ограничивают выборку.
+type ReplayEventsRequest struct {
+	UserID  string     `json:"user_id,omitempty" binding:"omitempty,uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	From    *time.Time `json:"from,omitempty" example:"2025-01-01T00:00:00Z"`
+	To      *time.Time `json:"to,omitempty" example:"2025-02-01T00:00:00Z"`
+	Types   []string   `json:"types,omitempty" example:"subscription.created.v1"`
+	AfterID int64      `json:"after_id,omitempty"`
+	Rate    int        `json:"rate,omitempty" example:"100"`
+	DryRun  bool       `json:"dry_run"`
+}
+
+// RegisterRoutes регистрирует маршруты /admin/events.
+// Доступ ограничивается через mws (authz.Middleware с правами admin:*).
+func (h *EventReplayHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/admin/events", mws...)
+	{
+		g.POST("replay", h.Replay)
+	}
+}
+
+// Replay godoc
+// @Summary		Повторно опубликовать события
+// @Description	Публикует события из журнала по фильтру (пользователь, период [from, to), типы, after_id) в порядке записи,
+// @Description	не быстрее rate событий в секунду (по умолчанию 100, не больше 1000). Сообщения получают заголовок replay=true,
+// @Description	потребители обрабатывают их повторно. dry_run: true только считает события.
+// @Description	Replay выполняется в фоне, одновременно один на все реплики; при ошибке публикации он останавливается, и last_id опубликованного события пишется в лог.
+// @Tags			admin
+// @Accept		json
+// @Produce		json
+// @Param			body	body		ReplayEventsRequest	true	"Фильтр и параметры"
+// @Success		200		{object}	model.ReplayResult	"dry_run"
+// @Success		202		{object}	model.ReplayResult	"replay запущен"
+// @Failure		400		{object}	map[string]string
+// @Failure		403		{object}	map[string]string
+// @Failure		409		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/admin/events/replay [post]
+func (h *EventReplayHandler) Replay(c *gin.Context) {
+	var in ReplayEventsRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 30*time.Second)
+	defer cancel()
+
+	f := model.EventLogFilter{UserID: in.UserID, From: in.From, To: in.To, Types: in.Types, AfterID: in.AfterID}
+	res, err := h.svc.Replay(ctx, f, in.Rate, in.DryRun)
+	switch {
+	case errors.Is(err, service.ErrInvalidReplay):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrReplayRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusAccepted
+	if res.DryRun || res.Matched == 0 {
+		status = http.StatusOK
+	}
+	c.JSON(status, res)
+}
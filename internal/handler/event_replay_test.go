@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventReplayService struct {
+	mock.Mock
+}
+
+func (m *MockEventReplayService) Replay(ctx context.Context, f model.EventLogFilter, rate int, dryRun bool) (*model.ReplayResult, error) {
+	args := m.Called(ctx, f, rate, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReplayResult), args.Error(1)
+}
+
+func TestEventReplayHandler_Replay(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockEventReplayService)
+		expectedStatus int
+	}{
+		{
+			name: "started",
+			body: `{"user_id": "` + userID + `", "rate": 50}`,
+			mockSetup: func(m *MockEventReplayService) {
+				m.On("Replay", mock.Anything, model.EventLogFilter{UserID: userID}, 50, false).
+					Return(&model.ReplayResult{Matched: 3, LastID: 7}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name: "dry run",
+			body: `{"dry_run": true}`,
+			mockSetup: func(m *MockEventReplayService) {
+				m.On("Replay", mock.Anything, model.EventLogFilter{}, 0, true).
+					Return(&model.ReplayResult{Matched: 3, LastID: 7, DryRun: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "user_id is not a uuid",
+			body:           `{"user_id": "alice"}`,
+			mockSetup:      func(*MockEventReplayService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "already running",
+			body: `{}`,
+			mockSetup: func(m *MockEventReplayService) {
+				m.On("Replay", mock.Anything, model.EventLogFilter{}, 0, false).Return(nil, service.ErrReplayRunning)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockSvc := new(MockEventReplayService)
+			tt.mockSetup(mockSvc)
+			r := gin.New()
+			NewEventReplayHandler(mockSvc).RegisterRoutes(r)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/events/replay", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeRateHandler загрузка и просмотр курсов валют (для администраторов)
+type ExchangeRateHandler struct {
+	svc service.ExchangeRateServiceInterface
+}
+
+func NewExchangeRateHandler(svc service.ExchangeRateServiceInterface) *ExchangeRateHandler {
+	return &ExchangeRateHandler{svc: svc}
+}
+
+// RegisterRoutes регистрирует маршруты /admin/exchange-rates.
+// Доступ ограничивается через mws (authz.Middleware с правами admin:*).
+func (h *ExchangeRateHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/admin/exchange-rates", mws...)
+	{
+		g.GET("", h.List)
+		g.PUT("", h.Upsert)
+	}
+}
+
+// List godoc
+// @Summary		Список курсов валют
+// @Tags			admin
+// @Produce		json
+// @Param			base	query	string	false	"Базовая валюта (ISO 4217)"
+// @Param			quote	query	string	false	"Котируемая валюта (ISO 4217)"
+// @Success		200		{array}		model.ExchangeRate
+// @Failure		403		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/admin/exchange-rates [get]
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	f := model.ExchangeRateFilter{
+		Base:  c.Query("base"),
+		Quote: c.Query("quote"),
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	rates, err := h.svc.List(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+// Upsert godoc
+// @Summary		Загрузить курсы валют
+// @Description	Принимает JSON массив курсов или CSV (Content-Type: text/csv) с колонками date,base,quote,rate.
+// @Description	1 base = rate quote; курс на ту же дату и пару перезаписывается.
+// @Tags			admin
+// @Accept		json
+// @Accept		text/csv
+// @Produce		json
+// @Param			body	body		[]model.ExchangeRate	true	"Курсы"
+// @Success		204		""
+// @Failure		400		{object}	map[string]string
+// @Failure		403		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/admin/exchange-rates [put]
+func (h *ExchangeRateHandler) Upsert(c *gin.Context) {
+	var (
+		rates []model.ExchangeRate
+		err   error
+	)
+	if c.ContentType() == "text/csv" {
+		rates, err = service.ParseRatesCSV(c.Request.Body)
+	} else {
+		err = c.ShouldBindJSON(&rates)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 30*time.Second)
+	defer cancel()
+
+	if err := h.svc.Upsert(ctx, rates); err != nil {
+		if errors.Is(err, service.ErrInvalidExchangeRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
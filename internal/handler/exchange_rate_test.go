@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExchangeRateService struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateService) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	return m.Called(ctx, rates).Error(0)
+}
+
+func (m *MockExchangeRateService) List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func TestExchangeRateHandler(t *testing.T) {
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	usdRub := []model.ExchangeRate{{Date: july, Base: "USD", Quote: "RUB", Rate: 81.25}}
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		mockSetup      func(*MockExchangeRateService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "upsert json",
+			method:      "PUT",
+			contentType: "application/json",
+			body:        `[{"date":"2025-07-01","base":"USD","quote":"RUB","rate":81.25}]`,
+			mockSetup: func(m *MockExchangeRateService) {
+				m.On("Upsert", mock.Anything, usdRub).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:        "upsert csv",
+			method:      "PUT",
+			contentType: "text/csv",
+			body:        "date,base,quote,rate\n2025-07-01,USD,RUB,81.25\n",
+			mockSetup: func(m *MockExchangeRateService) {
+				m.On("Upsert", mock.Anything, usdRub).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "invalid date",
+			method:         "PUT",
+			contentType:    "application/json",
+			body:           `[{"date":"07-2025","base":"USD","quote":"RUB","rate":81.25}]`,
+			mockSetup:      func(_ *MockExchangeRateService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: "GET",
+			mockSetup: func(m *MockExchangeRateService) {
+				m.On("List", mock.Anything, model.ExchangeRateFilter{}).Return(usdRub, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"base":"USD","quote":"RUB","rate":81.25,"date":"2025-07-01"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockExchangeRateService)
+			tt.mockSetup(mockSvc)
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			NewExchangeRateHandler(mockSvc).RegisterRoutes(r)
+
+			req := httptest.NewRequest(tt.method, "/admin/exchange-rates", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamHandler поток изменений подписок пользователя (Server-Sent Events)
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{hub: hub, heartbeat: heartbeat}
+}
+
+// RegisterRoutes регистрирует маршрут /subscriptions/stream.
+// mws — middleware авторизации, как у SubscriptionHandler.
+func (h *StreamHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/subscriptions", mws...)
+	{
+		g.GET("/stream", h.Stream)
+	}
+}
+
+// Stream godoc
+// @Summary		Поток изменений подписок (SSE)
+// @Description	Отправляет события подписок пользователя по мере их фиксации: id — номер события, event — тип CloudEvents
+// @Description	(subscription.created.v1, subscription.updated.v1, subscription.deleted.v1, …), data — конверт CloudEvents.
+// @Description	Каждые STREAM_HEARTBEAT отправляется комментарий ": heartbeat". При переподключении с заголовком Last-Event-ID
+// @Description	досылаются пропущенные события; если они уже вытеснены из буфера, первым приходит событие reset —
+// @Description	клиенту нужно перечитать подписки через GET /subscriptions. Клиент, не успевающий читать, отключается.
+// @Description	События видны только соединениям той же реплики, на которой произошло изменение.
+// @Tags			subscriptions
+// @Produce		text/event-stream
+// @Param			user_id			query		string	false	"ID пользователя (только при выключенной авторизации)"
+// @Param			Last-Event-ID	header		int		false	"id последнего полученного события"
+// @Success		200				{string}	string	"поток событий"
+// @Failure		400				{object}	map[string]string
+// @Router		/subscriptions/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+	var lastID uint64
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return
+		}
+		lastID = id
+	}
+
+	client, backlog, complete := h.hub.Subscribe(userID, lastID)
+	defer client.Close()
+
+	// соединение живёт дольше HTTP_WRITE_TIMEOUT сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	if !complete {
+		if _, err := io.WriteString(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+	for _, e := range backlog {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-client.Events():
+			if !ok {
+				// отстал от событий: клиент переподключится с Last-Event-ID
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// writeEvent пишет событие в формате text/event-stream; data — однострочный JSON
+func writeEvent(w io.Writer, e stream.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+	return err
+}
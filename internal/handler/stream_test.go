@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openStream подключается к потоку u1 и возвращает ответ
+func openStream(t *testing.T, srv *httptest.Server, lastID string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/subscriptions/stream?user_id=u1", nil)
+	require.NoError(t, err)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+// readFrame читает сообщение потока до пустой строки
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			return b.String()
+		}
+		b.WriteString(line)
+	}
+}
+
+// eventHeader первые строки сообщения без data
+func eventHeader(frame string) string {
+	head, _, _ := strings.Cut(frame, "data: ")
+	return head
+}
+
+func TestStreamHandler_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		lastID     string
+		wantStatus int
+		wantFrames []string // заголовки первых сообщений
+	}{
+		{
+			name:       "resume from buffer",
+			lastID:     "3",
+			wantStatus: http.StatusOK,
+			wantFrames: []string{"id: 4\nevent: subscription.updated.v1\n"},
+		},
+		{
+			name:       "reset when events evicted",
+			lastID:     "1",
+			wantStatus: http.StatusOK,
+			wantFrames: []string{"event: reset\n", "id: 3\nevent: subscription.updated.v1\n", "id: 4\nevent: subscription.updated.v1\n"},
+		},
+		{
+			name:       "new connection gets heartbeat",
+			wantStatus: http.StatusOK,
+			wantFrames: []string{": heartbeat\n"},
+		},
+		{
+			name:       "invalid Last-Event-ID",
+			lastID:     "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := stream.NewHub(2, 8)
+			r := gin.New()
+			NewStreamHandler(hub, 20*time.Millisecond).RegisterRoutes(r)
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+			defer hub.Close()
+
+			for range 4 {
+				hub.Notify("u1", &events.Envelope{ID: "e", Type: "subscription.updated.v1"})
+			}
+
+			resp := openStream(t, srv, tt.lastID)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+			body := bufio.NewReader(resp.Body)
+			for _, want := range tt.wantFrames {
+				assert.Equal(t, want, eventHeader(readFrame(t, body)))
+			}
+		})
+	}
+}
+
+func TestStreamHandler_LiveEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := stream.NewHub(10, 8)
+	r := gin.New()
+	NewStreamHandler(hub, time.Minute).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	defer hub.Close()
+
+	resp := openStream(t, srv, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	hub.Notify("u2", &events.Envelope{ID: "other", Type: "subscription.created.v1"})
+	hub.Notify("u1", &events.Envelope{ID: "mine", Type: "subscription.deleted.v1"})
+
+	frame := readFrame(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "id: 2\nevent: subscription.deleted.v1\n", eventHeader(frame))
+	assert.Contains(t, frame, `"id":"mine"`)
+}
@@ -2,12 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/iokiris/efm-subscription-api/internal/middleware"
-
 	"github.com/iokiris/efm-subscription-api/internal/model"
 	"github.com/iokiris/efm-subscription-api/internal/service"
 
@@ -23,24 +24,27 @@ func NewSubscriptionHandler(svc service.SubscriptionServiceInterface) *Subscript
 }
 
 // RegisterRoutes регистрирует маршруты для управления подписками.
-func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine, authRequired bool) {
-	g := r.Group("/subscriptions")
-	if authRequired {
-		g.Use(middleware.JWTMiddleware())
-	}
+// mws — middleware авторизации (middleware.JWTMiddleware, authz.Middleware), без них маршруты открыты.
+func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/subscriptions", mws...)
 	{
 		g.POST("", h.Create)
 		g.PUT(":id", h.Update)
 		g.DELETE(":id", h.Delete)
 		g.GET(":id", h.Get)
+		g.GET(":id/prices", h.Prices)
+		g.POST(":id/pause", h.Pause)
+		g.POST(":id/resume", h.Resume)
 		g.GET("", h.List)
 		g.GET("/summary", h.Summary)
+		g.GET("/search", h.Search)
+		g.GET("/autocomplete", h.Autocomplete)
 	}
 }
 
 // Create godoc
 // @Summary		Создать подписку
-// @Description	Создаёт новую подписку
+// @Description	Создаёт новую подписку. При включённой авторизации user_id берётся из токена.
 // @Tags			subscriptions
 // @Accept		json
 // @Produce		json
@@ -55,12 +59,15 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if userID, ok := authUserID(c); ok {
+		in.UserID = userID
+	}
 
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
 	if err := h.svc.Create(ctx, &in); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeServiceError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, in)
@@ -68,7 +75,7 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 
 // Update godoc
 // @Summary		Обновить подписку
-// @Description	Обновляет существующую подписку по ID
+// @Description	Обновляет существующую подписку по ID. Новая цена действует с текущего месяца, прошлые суммы не меняются.
 // @Tags			subscriptions
 // @Accept		json
 // @Produce		json
@@ -76,6 +83,7 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 // @Param			body	body		model.Subscription	true	"Данные подписки"
 // @Success		200		{object}	model.Subscription
 // @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
 // @Failure		500		{object}	map[string]string
 // @Router		/subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
@@ -91,12 +99,13 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		return
 	}
 	in.ID = id
+	userID, _ := authUserID(c)
 
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	if err := h.svc.Update(ctx, &in); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.Update(ctx, &in, userID); err != nil {
+		writeServiceError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, in)
@@ -110,6 +119,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 // @Param			id			path	int	true	"ID подписки"
 // @Success		204		""
 // @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
 // @Failure		500		{object}	map[string]string
 // @Router		/subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
@@ -122,9 +132,10 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	// user_id не требуется от клиента
-	if err := h.svc.Delete(ctx, id, ""); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// user_id не требуется от клиента: берётся из токена, если авторизация включена
+	userID, _ := authUserID(c)
+	if err := h.svc.Delete(ctx, id, userID); err != nil {
+		writeServiceError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -138,6 +149,7 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 // @Param			id	path	int	true	"ID подписки"
 // @Success		200	{object}	model.Subscription
 // @Failure		400	{object}	map[string]string
+// @Failure		404	{object}	map[string]string
 // @Failure		500	{object}	map[string]string
 // @Router		/subscriptions/{id} [get]
 func (h *SubscriptionHandler) Get(c *gin.Context) {
@@ -150,73 +162,340 @@ func (h *SubscriptionHandler) Get(c *gin.Context) {
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	sub, err := h.svc.Get(ctx, id)
+	userID, _ := authUserID(c)
+	sub, err := h.svc.Get(ctx, id, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeServiceError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, sub)
 }
 
+// Prices godoc
+// @Summary		История цен подписки
+// @Description	Возвращает цены по возрастанию effective_from; каждая действует до следующей записи.
+// @Tags			subscriptions
+// @Produce		json
+// @Param			id	path	int	true	"ID подписки"
+// @Success		200	{array}		model.SubscriptionPrice
+// @Failure		400	{object}	map[string]string
+// @Failure		404	{object}	map[string]string
+// @Failure		500	{object}	map[string]string
+// @Router		/subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) Prices(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	userID, _ := authUserID(c)
+	prices, err := h.svc.Prices(ctx, id, userID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+// PauseRequest тело запроса паузы/возобновления. Пустой from — текущий месяц.
+type PauseRequest struct {
+	From *model.MonthYear `json:"from,omitempty" swaggertype:"string" example:"07-2025"`
+}
+
+// Pause godoc
+// @Summary		Приостановить подписку
+// @Description	Месяцы паузы не входят в сумму. Пауза начинается с from (по умолчанию текущий месяц).
+// @Tags			subscriptions
+// @Accept		json
+// @Produce		json
+// @Param			id		path		int				true	"ID подписки"
+// @Param			body	body		PauseRequest	false	"Месяц начала паузы"
+// @Success		200		{object}	model.SubscriptionPause
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Failure		409		{object}	map[string]string
+// @Router		/subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	h.pauseOrResume(c, h.svc.Pause)
+}
+
+// Resume godoc
+// @Summary		Возобновить подписку
+// @Description	Завершает паузу: подписка снова оплачивается с from (по умолчанию текущий месяц).
+// @Tags			subscriptions
+// @Accept		json
+// @Produce		json
+// @Param			id		path		int				true	"ID подписки"
+// @Param			body	body		PauseRequest	false	"Месяц возобновления"
+// @Success		200		{object}	model.SubscriptionPause
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Failure		409		{object}	map[string]string
+// @Router		/subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	h.pauseOrResume(c, h.svc.Resume)
+}
+
+func (h *SubscriptionHandler) pauseOrResume(c *gin.Context, op func(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error)) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in PauseRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var from time.Time
+	if in.From != nil {
+		from = time.Time(*in.From)
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	userID, _ := authUserID(c)
+	pause, err := op(ctx, id, userID, from)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pause)
+}
+
 // List godoc
 // @Summary		Список подписок пользователя
-// @Description	Возвращает список подписок по user_id
+// @Description	Возвращает страницу подписок по user_id. При включённой авторизации user_id берётся из токена.
+// @Description	Следующая страница — тот же запрос с cursor=next_cursor; пустой next_cursor — страница последняя.
+// @Description	По умолчанию новые подписки первыми; sort без order — по возрастанию.
 // @Tags			subscriptions
 // @Produce		json
-// @Param			user_id	query	string	true	"ID пользователя (UUID)"
-// @Success		200		{array}		model.Subscription
+// @Param			user_id			query	string	false	"ID пользователя (UUID), только без авторизации"
+// @Param			trial			query	string	false	"Пробный период: active, converted или none"
+// @Param			service_name	query	string	false	"Имя сервиса"
+// @Param			active_on		query	string	false	"Подписка действует в месяце даты (YYYY-MM-DD или MM-YYYY)"
+// @Param			price_min		query	int		false	"Минимальная цена"
+// @Param			price_max		query	int		false	"Максимальная цена"
+// @Param			status			query	string	false	"Состояние на текущий месяц: active, paused или ended"
+// @Param			sort			query	string	false	"Сортировка: created_at, price, start_date или service_name"
+// @Param			order			query	string	false	"Направление: asc или desc"
+// @Param			limit			query	int		false	"Размер страницы, 1..100 (по умолчанию 20)"
+// @Param			cursor			query	string	false	"next_cursor предыдущей страницы"
+// @Success		200		{object}	model.SubscriptionPage
 // @Failure		400		{object}	map[string]string
 // @Failure		500		{object}	map[string]string
 // @Router		/subscriptions [get]
 func (h *SubscriptionHandler) List(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := requestUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+	trial, ok := trialQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	page, err := h.svc.List(ctx, model.SubscriptionListQuery{
+		UserID:   userID,
+		Trial:    trial,
+		Service:  c.Query("service_name"),
+		ActiveOn: c.Query("active_on"),
+		PriceMin: c.Query("price_min"),
+		PriceMax: c.Query("price_max"),
+		Status:   c.Query("status"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+		Limit:    c.Query("limit"),
+		Cursor:   c.Query("cursor"),
+	})
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Search godoc
+// @Summary		Нечёткий поиск подписок по имени сервиса
+// @Description	Ищет подписки пользователя, имя сервиса которых похоже на q: с опечатками ("netflx") или по части имени ("yandex").
+// @Description	Результаты отсортированы по убыванию score — сходства с запросом от 0 до 1.
+// @Tags			subscriptions
+// @Produce		json
+// @Param			q		query		string	true	"Запрос, до 100 символов"
+// @Param			user_id	query		string	false	"ID пользователя (UUID), только без авторизации"
+// @Param			limit	query		int		false	"Число результатов, 1..100 (по умолчанию 20)"
+// @Success		200		{array}		model.SubscriptionMatch
+// @Failure		400		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/subscriptions/search [get]
+func (h *SubscriptionHandler) Search(c *gin.Context) {
+	userID := requestUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
 
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	subs, err := h.svc.List(ctx, userID)
+	matches, err := h.svc.Search(ctx, userID, c.Query("q"), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, subs)
+	c.JSON(http.StatusOK, matches)
+}
+
+// Autocomplete godoc
+// @Summary		Подсказки имён сервисов
+// @Description	Имена сервисов из подписок всех пользователей, похожие на q: сначала начинающиеся с q, затем по сходству и популярности.
+// @Tags			subscriptions
+// @Produce		json
+// @Param			q		query		string	true	"Введённая часть имени, до 100 символов"
+// @Param			limit	query		int		false	"Число подсказок, 1..100 (по умолчанию 20)"
+// @Success		200		{array}		string
+// @Failure		400		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/subscriptions/autocomplete [get]
+func (h *SubscriptionHandler) Autocomplete(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	names, err := h.svc.SuggestServices(ctx, c.Query("q"), limit)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, names)
+}
+
+// limitQuery читает необязательный limit; 0 — по умолчанию сервиса
+func limitQuery(c *gin.Context) (int, bool) {
+	v := c.Query("limit")
+	if v == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return 0, false
+	}
+	return limit, true
+}
+
+// trialQuery читает фильтр trial из запроса; при неверном значении отвечает 400
+func trialQuery(c *gin.Context) (model.TrialState, bool) {
+	trial := model.TrialState(c.Query("trial"))
+	if !trial.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trial, expected active, converted or none"})
+		return "", false
+	}
+	return trial, true
 }
 
 // Summary godoc
 // @Summary		Сумма по подпискам за период
-// @Description	Возвращает сумму цен подписок пользователя, пересекающих интервал [from; to]
+// @Description	Возвращает расходы пользователя за интервал [from; to]: цена каждой подписки × число её дат оплаты внутри интервала
+// @Description	(по billing_period/billing_interval). В items — вклад каждой подписки и число списаний. Пустой to — по текущий месяц.
+// @Description	Суммы пересчитываются в currency по курсу месяца списания; списания без курса не учитываются и перечислены в missing_rates.
+// @Description	group_by добавляет breakdown: by_service — суммы по сервисам, by_month — помесячный ряд; service,month — ещё и ряд внутри каждого сервиса.
 // @Tags			subscriptions
 // @Produce		json
-// @Param			user_id			query	string	true	"ID пользователя"
+// @Param			user_id			query	string	false	"ID пользователя, только без авторизации"
 // @Param			service_name	query	string	false	"Имя сервиса"
 // @Param			from			query	string	false	"Дата начала (RFC3339, YYYY-MM-DD или MM-YYYY)"
 // @Param			to			query	string	false	"Дата конца (RFC3339, YYYY-MM-DD или MM-YYYY)"
-// @Success		200		{object}	map[string]int
+// @Param			group_by		query	string	false	"Разбивка: service, month или service,month"
+// @Param			currency		query	string	false	"Валюта суммы (ISO 4217), по умолчанию RUB"
+// @Success		200		{object}	model.Summary
 // @Failure		400		{object}	map[string]string
 // @Router		/subscriptions/summary [get]
 func (h *SubscriptionHandler) Summary(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := requestUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
-	serviceName := c.Query("service_name")
-	from := c.Query("from")
-	to := c.Query("to")
+	q := summaryQuery(c)
+	q.UserID = userID
 
 	ctx, cancel := contextWithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	total, err := h.svc.GetSummary(ctx, userID, serviceName, from, to)
+	summary, err := h.svc.GetSummary(ctx, q)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"total": total})
+	c.JSON(http.StatusOK, summary)
+}
+
+// summaryQuery читает общие параметры суммы; user_id выставляет вызывающий
+func summaryQuery(c *gin.Context) model.SummaryQuery {
+	q := model.SummaryQuery{
+		Service:  c.Query("service_name"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Currency: c.Query("currency"),
+	}
+	if groupBy := c.Query("group_by"); groupBy != "" {
+		for _, g := range strings.Split(groupBy, ",") {
+			q.GroupBy = append(q.GroupBy, strings.TrimSpace(g))
+		}
+	}
+	return q
+}
+
+// authUserID возвращает user_id, положенный middleware авторизации.
+// ok == false — авторизация для маршрута выключена.
+func authUserID(c *gin.Context) (string, bool) {
+	userID := c.GetString(middleware.ContextUserID)
+	return userID, userID != ""
+}
+
+// requestUserID возвращает пользователя из токена, а без авторизации — из query-параметра user_id
+func requestUserID(c *gin.Context) string {
+	if userID, ok := authUserID(c); ok {
+		return userID
+	}
+	return c.Query("user_id")
+}
+
+// writeServiceError отдаёт 404 для чужих/несуществующих подписок, иначе 500
+func writeServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidSubscription), errors.Is(err, service.ErrInvalidListQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrAlreadyPaused), errors.Is(err, service.ErrNotPaused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 func parseIDParam(s string) (int64, error) {
@@ -5,13 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
 
+	"github.com/iokiris/efm-subscription-api/internal/middleware"
 	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -28,8 +31,8 @@ func (m *MockSubscriptionService) Create(ctx context.Context, sub *model.Subscri
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) Update(ctx context.Context, sub *model.Subscription) error {
-	args := m.Called(ctx, sub)
+func (m *MockSubscriptionService) Update(ctx context.Context, sub *model.Subscription, userID string) error {
+	args := m.Called(ctx, sub, userID)
 	return args.Error(0)
 }
 
@@ -38,25 +41,76 @@ func (m *MockSubscriptionService) Delete(ctx context.Context, id int64, userID s
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) Get(ctx context.Context, id int64) (*model.Subscription, error) {
-	args := m.Called(ctx, id)
+func (m *MockSubscriptionService) Get(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	args := m.Called(ctx, id, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionService) List(ctx context.Context, userID string) ([]model.Subscription, error) {
-	args := m.Called(ctx, userID)
+func (m *MockSubscriptionService) List(ctx context.Context, q model.SubscriptionListQuery) (*model.SubscriptionPage, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPage), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error) {
+	args := m.Called(ctx, f)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]model.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionService) GetSummary(ctx context.Context, userID, serviceName, from, to string) (int, error) {
-	args := m.Called(ctx, userID, serviceName, from, to)
-	return args.Int(0), args.Error(1)
+func (m *MockSubscriptionService) Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error) {
+	args := m.Called(ctx, userID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SubscriptionMatch), args.Error(1)
+}
+
+func (m *MockSubscriptionService) SuggestServices(ctx context.Context, query string, limit int) ([]string, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSubscriptionService) Prices(ctx context.Context, id int64, userID string) ([]model.SubscriptionPrice, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SubscriptionPrice), args.Error(1)
+}
+
+func (m *MockSubscriptionService) Pause(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error) {
+	args := m.Called(ctx, id, userID, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPause), args.Error(1)
+}
+
+func (m *MockSubscriptionService) Resume(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error) {
+	args := m.Called(ctx, id, userID, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPause), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetSummary(ctx context.Context, q model.SummaryQuery) (*model.Summary, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Summary), args.Error(1)
 }
 
 func setupTestRouter(mockSvc *MockSubscriptionService) *gin.Engine {
@@ -64,7 +118,21 @@ func setupTestRouter(mockSvc *MockSubscriptionService) *gin.Engine {
 	r := gin.New()
 
 	h := &SubscriptionHandler{svc: mockSvc}
-	h.RegisterRoutes(r, false)
+	h.RegisterRoutes(r)
+
+	return r
+}
+
+// setupAuthTestRouter роутер с заглушкой авторизации, кладущей userID в контекст
+func setupAuthTestRouter(mockSvc *MockSubscriptionService, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	h := &SubscriptionHandler{svc: mockSvc}
+	h.RegisterRoutes(r, func(c *gin.Context) {
+		c.Set(middleware.ContextUserID, userID)
+		c.Next()
+	})
 
 	return r
 }
@@ -121,6 +189,23 @@ func TestSubscriptionHandler_Create(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
 		},
+		{
+			name: "invalid billing period",
+			requestBody: map[string]interface{}{
+				"service_name":   "Yandex Plus",
+				"price":          4000,
+				"billing_period": "day",
+				"user_id":        "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+				"start_date":     "07-2025",
+			},
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Create", mock.Anything, mock.MatchedBy(func(sub *model.Subscription) bool {
+					return sub.BillingPeriod == "day"
+				})).Return(fmt.Errorf("%w: invalid billing_period", service.ErrInvalidSubscription))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,7 +259,7 @@ func TestSubscriptionHandler_Get(t *testing.T) {
 					UserID:    "60601fee-2bf1-4721-ae6f-7636e79a0cba",
 					StartDate: model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)),
 				}
-				m.On("Get", mock.Anything, int64(1)).Return(sub, nil)
+				m.On("Get", mock.Anything, int64(1), "").Return(sub, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
@@ -191,7 +276,7 @@ func TestSubscriptionHandler_Get(t *testing.T) {
 			name: "not found",
 			id:   "999",
 			mockSetup: func(m *MockSubscriptionService) {
-				m.On("Get", mock.Anything, int64(999)).Return(nil, errors.New("not found"))
+				m.On("Get", mock.Anything, int64(999), "").Return(nil, errors.New("not found"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
@@ -247,7 +332,7 @@ func TestSubscriptionHandler_Update(t *testing.T) {
 						sub.Price == 500 &&
 						sub.UserID == "60601fee-2bf1-4721-ae6f-7636e79a0cba" &&
 						sub.StartDate == model.MonthYear(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
-				})).Return(nil)
+				}), "").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
@@ -358,6 +443,7 @@ func TestSubscriptionHandler_List(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
+		query          string
 		mockSetup      func(*MockSubscriptionService)
 		expectedStatus int
 		expectedError  bool
@@ -375,11 +461,44 @@ func TestSubscriptionHandler_List(t *testing.T) {
 						StartDate: model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)),
 					},
 				}
-				m.On("List", mock.Anything, "60601fee-2bf1-4721-ae6f-7636e79a0cba").Return(subs, nil)
+				m.On("List", mock.Anything, model.SubscriptionListQuery{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba"}).
+					Return(&model.SubscriptionPage{Items: subs, NextCursor: "abc"}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
 		},
+		{
+			name:   "filters, sort and cursor passed through",
+			userID: "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+			query:  "&service_name=Netflix&active_on=2025-07-15&price_min=100&price_max=500&status=active&sort=price&order=desc&limit=1&cursor=abc",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("List", mock.Anything, model.SubscriptionListQuery{
+					UserID:   "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+					Service:  "Netflix",
+					ActiveOn: "2025-07-15",
+					PriceMin: "100",
+					PriceMax: "500",
+					Status:   "active",
+					Sort:     "price",
+					Order:    "desc",
+					Limit:    "1",
+					Cursor:   "abc",
+				}).Return(&model.SubscriptionPage{Items: []model.Subscription{
+					{ID: 1, Service: "Netflix", StartDate: model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))},
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "invalid query",
+			userID: "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+			query:  "&sort=color",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("List", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: invalid sort", service.ErrInvalidListQuery))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  true,
+		},
 		{
 			name:   "missing user_id",
 			userID: "",
@@ -392,7 +511,7 @@ func TestSubscriptionHandler_List(t *testing.T) {
 			name:   "service error",
 			userID: "60601fee-2bf1-4721-ae6f-7636e79a0cba",
 			mockSetup: func(m *MockSubscriptionService) {
-				m.On("List", mock.Anything, "60601fee-2bf1-4721-ae6f-7636e79a0cba").Return(nil, errors.New("database error"))
+				m.On("List", mock.Anything, model.SubscriptionListQuery{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba"}).Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
@@ -408,7 +527,7 @@ func TestSubscriptionHandler_List(t *testing.T) {
 
 			u := "/subscriptions"
 			if tt.userID != "" {
-				u += "?user_id=" + tt.userID
+				u += "?user_id=" + tt.userID + tt.query
 			}
 
 			req := httptest.NewRequest("GET", u, nil)
@@ -423,10 +542,13 @@ func TestSubscriptionHandler_List(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Contains(t, response, "error")
 			} else if tt.userID != "" {
-				var response []model.Subscription
+				var response struct {
+					Items      []model.Subscription `json:"items"`
+					NextCursor string               `json:"next_cursor"`
+				}
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Len(t, response, 1)
+				assert.Len(t, response.Items, 1)
 			}
 
 			mockSvc.AssertExpectations(t)
@@ -434,6 +556,87 @@ func TestSubscriptionHandler_List(t *testing.T) {
 	}
 }
 
+func TestSubscriptionHandler_Search(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	start := model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name           string
+		url            string
+		mockSetup      func(*MockSubscriptionService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "ranked matches",
+			url:  "/subscriptions/search?user_id=" + userID + "&q=netflx&limit=5",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Search", mock.Anything, userID, "netflx", 5).Return([]model.SubscriptionMatch{
+					{Subscription: model.Subscription{ID: 1, Service: "Netflix", StartDate: start}, Score: 0.5},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"score":0.5`,
+		},
+		{
+			name: "empty query",
+			url:  "/subscriptions/search?user_id=" + userID,
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Search", mock.Anything, userID, "", 0).Return(nil, fmt.Errorf("%w: q is required", service.ErrInvalidListQuery))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "q is required",
+		},
+		{
+			name:           "invalid limit",
+			url:            "/subscriptions/search?user_id=" + userID + "&q=netflix&limit=ten",
+			mockSetup:      func(*MockSubscriptionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid limit",
+		},
+		{
+			name:           "missing user_id",
+			url:            "/subscriptions/search?q=netflix",
+			mockSetup:      func(*MockSubscriptionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "user_id is required",
+		},
+		{
+			name: "autocomplete across users",
+			url:  "/subscriptions/autocomplete?q=yand",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("SuggestServices", mock.Anything, "yand", 0).Return([]string{"Yandex Plus", "Yandex Music"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `["Yandex Plus","Yandex Music"]`,
+		},
+		{
+			name: "autocomplete service error",
+			url:  "/subscriptions/autocomplete?q=yand",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("SuggestServices", mock.Anything, "yand", 0).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "database error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockSubscriptionService)
+			tt.mockSetup(mockSvc)
+			router := setupTestRouter(mockSvc)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
 func TestSubscriptionHandler_Summary(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -447,7 +650,7 @@ func TestSubscriptionHandler_Summary(t *testing.T) {
 			name:        "successful summary",
 			queryParams: "user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba",
 			mockSetup: func(m *MockSubscriptionService) {
-				m.On("GetSummary", mock.Anything, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "", "", "").Return(1200, nil)
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba"}).Return(&model.Summary{Total: 1200}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
@@ -455,14 +658,30 @@ func TestSubscriptionHandler_Summary(t *testing.T) {
 		},
 		{
 			name:        "summary with filters",
-			queryParams: "user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba&service_name=Yandex Plus&from=07-2025&to=08-2025",
+			queryParams: "user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba&service_name=Yandex Plus&from=07-2025&to=08-2025&currency=USD",
 			mockSetup: func(m *MockSubscriptionService) {
-				m.On("GetSummary", mock.Anything, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "Yandex Plus", "07-2025", "08-2025").Return(400, nil)
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{
+					UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", Service: "Yandex Plus", From: "07-2025", To: "08-2025", Currency: "USD",
+				}).Return(&model.Summary{Total: 400}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
 			expectedTotal:  400,
 		},
+		{
+			name:        "summary with group_by",
+			queryParams: "user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba&group_by=service,%20month",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{
+					UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", GroupBy: []string{"service", "month"},
+				}).Return(&model.Summary{Total: 300, Breakdown: &model.SummaryBreakdown{
+					ByService: []model.ServiceTotal{{Service: "Netflix", Total: 300}},
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedError:  false,
+			expectedTotal:  300,
+		},
 		{
 			name:        "missing user_id",
 			queryParams: "",
@@ -476,7 +695,7 @@ func TestSubscriptionHandler_Summary(t *testing.T) {
 			name:        "invalid date format",
 			queryParams: "user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba&from=invalid-date",
 			mockSetup: func(m *MockSubscriptionService) {
-				m.On("GetSummary", mock.Anything, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "", "invalid-date", "").Return(0, errors.New("invalid date format"))
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", From: "invalid-date"}).Return(nil, errors.New("invalid date format"))
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  true,
@@ -519,3 +738,168 @@ func TestSubscriptionHandler_Summary(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionHandler_Ownership(t *testing.T) {
+	const owner = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	const other = "0b1e4f5a-1111-4c2d-9e3f-000000000000"
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           map[string]interface{}
+		mockSetup      func(*MockSubscriptionService)
+		expectedStatus int
+	}{
+		{
+			name:   "get own subscription",
+			method: "GET",
+			url:    "/subscriptions/1",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Get", mock.Anything, int64(1), owner).Return(&model.Subscription{ID: 1, UserID: owner}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "get foreign subscription",
+			method: "GET",
+			url:    "/subscriptions/2",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Get", mock.Anything, int64(2), owner).Return(nil, service.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "prices of own subscription",
+			method: "GET",
+			url:    "/subscriptions/1/prices",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Prices", mock.Anything, int64(1), owner).Return([]model.SubscriptionPrice{
+					{EffectiveFrom: model.MonthYear(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)), Price: 400},
+					{EffectiveFrom: model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)), Price: 500},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "prices of foreign subscription",
+			method: "GET",
+			url:    "/subscriptions/2/prices",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Prices", mock.Anything, int64(2), owner).Return(nil, service.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "pause from given month",
+			method: "POST",
+			url:    "/subscriptions/1/pause",
+			body:   map[string]interface{}{"from": "08-2025"},
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Pause", mock.Anything, int64(1), owner, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)).
+					Return(&model.SubscriptionPause{ID: 7, SubscriptionID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "pause already paused",
+			method: "POST",
+			url:    "/subscriptions/1/pause",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Pause", mock.Anything, int64(1), owner, time.Time{}).Return(nil, service.ErrAlreadyPaused)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "resume not paused",
+			method: "POST",
+			url:    "/subscriptions/1/resume",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Resume", mock.Anything, int64(1), owner, time.Time{}).Return(nil, service.ErrNotPaused)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "delete foreign subscription",
+			method: "DELETE",
+			url:    "/subscriptions/2",
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Delete", mock.Anything, int64(2), owner).Return(service.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update foreign subscription",
+			method: "PUT",
+			url:    "/subscriptions/2",
+			body: map[string]interface{}{
+				"service_name": "Yandex Plus",
+				"price":        400,
+				"user_id":      other,
+				"start_date":   "07-2025",
+			},
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Update", mock.Anything, mock.AnythingOfType("*model.Subscription"), owner).Return(service.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "create ignores user_id from body",
+			method: "POST",
+			url:    "/subscriptions",
+			body: map[string]interface{}{
+				"service_name": "Yandex Plus",
+				"price":        400,
+				"user_id":      other,
+				"start_date":   "07-2025",
+			},
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("Create", mock.Anything, mock.MatchedBy(func(sub *model.Subscription) bool {
+					return sub.UserID == owner
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "list ignores user_id query",
+			method: "GET",
+			url:    "/subscriptions?user_id=" + other,
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("List", mock.Anything, model.SubscriptionListQuery{UserID: owner}).Return(&model.SubscriptionPage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "summary ignores user_id query",
+			method: "GET",
+			url:    "/subscriptions/summary?user_id=" + other,
+			mockSetup: func(m *MockSubscriptionService) {
+				m.On("GetSummary", mock.Anything, model.SummaryQuery{UserID: owner}).Return(&model.Summary{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockSubscriptionService)
+			tt.mockSetup(mockSvc)
+
+			router := setupAuthTestRouter(mockSvc, owner)
+
+			var req *http.Request
+			if tt.body != nil {
+				body, _ := json.Marshal(tt.body)
+				req = httptest.NewRequest(tt.method, tt.url, bytes.NewBuffer(body))
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				req = httptest.NewRequest(tt.method, tt.url, nil)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
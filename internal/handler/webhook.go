@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler управление webhooks текущего пользователя и просмотр доставок
+type WebhookHandler struct {
+	svc service.WebhookServiceInterface
+}
+
+func NewWebhookHandler(svc service.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// CreateWebhookRequest тело запроса на регистрацию webhook.
+// Пустой secret генерируется; пустой events — все события.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required" example:"https://example.com/hooks/subscriptions"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events" example:"subscription.created.v1,subscription.deleted.v1"`
+}
+
+// CreateWebhookResponse ответ с секретом подписи. Секрет показывается один раз.
+type CreateWebhookResponse struct {
+	Secret  string        `json:"secret"`
+	Webhook model.Webhook `json:"webhook"`
+}
+
+// UpdateWebhookRequest тело запроса на изменение webhook. Без enabled включённость не меняется.
+type UpdateWebhookRequest struct {
+	URL     string   `json:"url" binding:"required"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// RegisterRoutes регистрирует маршруты /webhooks. Требует авторизации через mws.
+func (h *WebhookHandler) RegisterRoutes(r *gin.Engine, mws ...gin.HandlerFunc) {
+	g := r.Group("/webhooks", mws...)
+	{
+		g.POST("", h.Create)
+		g.GET("", h.List)
+		g.GET(":id", h.Get)
+		g.PUT(":id", h.Update)
+		g.DELETE(":id", h.Delete)
+		g.GET(":id/deliveries", h.Deliveries)
+		g.GET(":id/deliveries/:delivery_id/attempts", h.Attempts)
+	}
+}
+
+// Create godoc
+// @Summary		Зарегистрировать webhook
+// @Description	Подписывает url на события подписок пользователя. Каждое событие отправляется POST-запросом
+// @Description	с конвертом CloudEvents и заголовками X-Signature: sha256=<hex> и X-Signature-Timestamp: <unix>,
+// @Description	где подпись — HMAC-SHA256(secret, "<timestamp>.<body>"). Ответ не 2xx повторяется с экспоненциальной задержкой.
+// @Description	Секрет возвращается только в этом ответе.
+// @Tags			webhooks
+// @Accept		json
+// @Produce		json
+// @Param			body	body		CreateWebhookRequest	true	"Параметры webhook"
+// @Success		201		{object}	CreateWebhookResponse
+// @Failure		400		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var in CreateWebhookRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	w := &model.Webhook{UserID: userID, URL: in.URL, Secret: in.Secret, Events: in.Events}
+	secret, err := h.svc.Create(ctx, w)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, CreateWebhookResponse{Secret: secret, Webhook: *w})
+}
+
+// List godoc
+// @Summary		Список webhooks
+// @Description	Возвращает webhooks текущего пользователя без секретов
+// @Tags			webhooks
+// @Produce		json
+// @Success		200		{array}		model.Webhook
+// @Failure		500		{object}	map[string]string
+// @Router		/webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	hooks, err := h.svc.List(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// Get godoc
+// @Summary		Получить webhook
+// @Tags			webhooks
+// @Produce		json
+// @Param			id	path		int	true	"ID webhook"
+// @Success		200	{object}	model.Webhook
+// @Failure		400	{object}	map[string]string
+// @Failure		404	{object}	map[string]string
+// @Failure		500	{object}	map[string]string
+// @Router		/webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	w, err := h.svc.Get(ctx, id, userID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// Update godoc
+// @Summary		Изменить webhook
+// @Description	Меняет url и события. enabled: true включает webhook, выключенный после неудачных доставок, и сбрасывает счётчик неудач.
+// @Tags			webhooks
+// @Accept		json
+// @Produce		json
+// @Param			id		path		int						true	"ID webhook"
+// @Param			body	body		UpdateWebhookRequest	true	"Новые параметры"
+// @Success		200		{object}	model.Webhook
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var in UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	w := &model.Webhook{ID: id, UserID: userID, URL: in.URL, Events: in.Events}
+	if in.Enabled != nil {
+		w.Enabled = *in.Enabled
+	} else {
+		current, err := h.svc.Get(ctx, id, userID)
+		if err != nil {
+			writeWebhookError(c, err)
+			return
+		}
+		w.Enabled = current.Enabled
+	}
+
+	if err := h.svc.Update(ctx, w); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// Delete godoc
+// @Summary		Удалить webhook
+// @Description	Удаляет webhook вместе с историей доставок
+// @Tags			webhooks
+// @Produce		json
+// @Param			id	path	int	true	"ID webhook"
+// @Success		204		""
+// @Failure		400		{object}	map[string]string
+// @Failure		404		{object}	map[string]string
+// @Failure		500		{object}	map[string]string
+// @Router		/webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := h.svc.Delete(ctx, id, userID); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries godoc
+// @Summary		Доставки webhook
+// @Description	Последние 100 доставок, новые первыми. status: pending — ждёт отправки или повтора, delivered, failed — попытки исчерпаны.
+// @Tags			webhooks
+// @Produce		json
+// @Param			id	path		int	true	"ID webhook"
+// @Success		200	{array}		model.WebhookDelivery
+// @Failure		400	{object}	map[string]string
+// @Failure		404	{object}	map[string]string
+// @Failure		500	{object}	map[string]string
+// @Router		/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	deliveries, err := h.svc.Deliveries(ctx, id, userID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Attempts godoc
+// @Summary		Попытки доставки
+// @Description	Попытки доставки по порядку: код ответа получателя или ошибка соединения
+// @Tags			webhooks
+// @Produce		json
+// @Param			id			path		int	true	"ID webhook"
+// @Param			delivery_id	path		int	true	"ID доставки"
+// @Success		200			{array}		model.WebhookAttempt
+// @Failure		400			{object}	map[string]string
+// @Failure		404			{object}	map[string]string
+// @Failure		500			{object}	map[string]string
+// @Router		/webhooks/{id}/deliveries/{delivery_id}/attempts [get]
+func (h *WebhookHandler) Attempts(c *gin.Context) {
+	id, err := parseIDParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	deliveryID, err := parseIDParam(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery_id"})
+		return
+	}
+	userID, _ := authUserID(c)
+
+	ctx, cancel := contextWithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	attempts, err := h.svc.Attempts(ctx, id, deliveryID, userID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,21 @@
+package infra
+
+import (
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// NewKafkaWriter создаёт writer, который ждёт подтверждения всех реплик.
+// Топик задаётся в каждом сообщении и создаётся брокером при первой записи, если это разрешено.
+// Соединения открываются лениво, при первой записи.
+func NewKafkaWriter(brokers []string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		// синхронная запись: не ждать наполнения батча
+		BatchTimeout: 10 * time.Millisecond,
+	}
+}
@@ -31,6 +31,12 @@ type Metrics struct {
 	// RabbitMQ метрики
 	RabbitMQMessagesPublished *prometheus.CounterVec
 	RabbitMQMessagesFailed    *prometheus.CounterVec
+
+	// Метрики фоновых задач
+	WorkerJobRunsTotal   *prometheus.CounterVec
+	WorkerJobDuration    *prometheus.HistogramVec
+	WorkerItemsProcessed *prometheus.CounterVec
+	LeaderStatus         prometheus.Gauge
 }
 
 func NewMetrics() *Metrics {
@@ -158,5 +164,38 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"exchange", "routing_key", "error_type"},
 		),
+
+		// Метрики фоновых задач
+		WorkerJobRunsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "worker_job_runs_total",
+				Help: "Total number of background job runs",
+			},
+			[]string{"job", "status"},
+		),
+
+		WorkerJobDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "worker_job_duration_seconds",
+				Help:    "Background job run duration in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"job"},
+		),
+
+		WorkerItemsProcessed: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "worker_items_processed_total",
+				Help: "Total number of subscriptions processed by background jobs",
+			},
+			[]string{"job"},
+		),
+
+		LeaderStatus: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "worker_leader",
+				Help: "1 if this instance holds the background jobs leader lock, 0 otherwise",
+			},
+		),
 	}
 }
@@ -0,0 +1,51 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// natsDuplicateWindow окно дедупликации stream по Nats-Msg-Id
+const natsDuplicateWindow = 10 * time.Minute
+
+// NewJetStream подключается к NATS и создаёт (или обновляет) stream name для subjects <exchange>.>.
+// Разрывы соединения после подключения клиент обрабатывает сам, переподключаясь без ограничения попыток.
+func NewJetStream(ctx context.Context, url, name, exchange string) (*nats.Conn, jetstream.JetStream, error) {
+	nc, err := nats.Connect(url,
+		nats.Name("efm-subscription-api"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.L.Error("nats.connection.lost", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.L.Info("nats.reconnect.ok", zap.String("url", nc.ConnectedUrl()))
+		}),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("nats connect: %w", err)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("nats jetstream: %w", err)
+	}
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       name,
+		Subjects:   []string{exchange + ".>"},
+		Storage:    jetstream.FileStorage,
+		Duplicates: natsDuplicateWindow,
+	}); err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("nats stream %s: %w", name, err)
+	}
+	return nc, js, nil
+}
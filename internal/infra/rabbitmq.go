@@ -1,9 +1,31 @@
 package infra
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
 
 	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// Границы задержки между попытками переподключения
+const (
+	rabbitMinBackoff = time.Second
+	rabbitMaxBackoff = 30 * time.Second
+)
+
+var (
+	// ErrRabbitUnavailable нет соединения с брокером (идёт переподключение)
+	ErrRabbitUnavailable = errors.New("rabbitmq unavailable")
+	// ErrRabbitNack брокер не принял сообщение
+	ErrRabbitNack = errors.New("rabbitmq nack")
+	// ErrRabbitClosed соединение закрыто через Close
+	ErrRabbitClosed = errors.New("rabbitmq closed")
 )
 
 type RabbitConfig struct {
@@ -13,19 +35,53 @@ type RabbitConfig struct {
 	Port     int
 }
 
-func NewRabbitMQ(cfg *RabbitConfig) (*amqp.Connection, *amqp.Channel, error) {
-	url := fmt.Sprintf("amqp://%s:%s@%s:%d/",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port,
-	)
-	conn, err := amqp.Dial(url)
+// Rabbit соединение с RabbitMQ с подтверждениями публикации (publisher confirms).
+// После разрыва соединения или канала переподключается с экспоненциальной задержкой;
+// сообщения, ожидавшие подтверждения, получают ErrRabbitUnavailable.
+type Rabbit struct {
+	url string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	seq     uint64                // DeliveryTag последней публикации в текущем канале
+	pending map[uint64]chan error // ожидающие подтверждения по DeliveryTag
+
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// NewRabbitMQ подключается к брокеру и объявляет exchange subscriptions.
+// Первое подключение должно удаться; дальнейшие разрывы обрабатываются в фоне.
+func NewRabbitMQ(cfg *RabbitConfig) (*Rabbit, error) {
+	r := &Rabbit{
+		url: fmt.Sprintf("amqp://%s:%s@%s:%d/",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port,
+		),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	lost, err := r.connect()
 	if err != nil {
-		return nil, nil, fmt.Errorf("rabbitmq connect: %w", err)
+		return nil, err
+	}
+	go r.watch(lost)
+	return r, nil
+}
+
+// connect открывает соединение и канал в режиме подтверждений.
+// Возвращает канал, который закрывается при разрыве соединения или канала.
+func (r *Rabbit) connect() (<-chan struct{}, error) {
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq connect: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return nil, nil, fmt.Errorf("rabbitmq channel: %w", err)
+		return nil, fmt.Errorf("rabbitmq channel: %w", err)
 	}
 
 	if err := ch.ExchangeDeclare(
@@ -38,8 +94,152 @@ func NewRabbitMQ(cfg *RabbitConfig) (*amqp.Connection, *amqp.Channel, error) {
 		nil,             // args
 	); err != nil {
 		conn.Close()
-		return nil, nil, fmt.Errorf("rabbitmq exchange declare: %w", err)
+		return nil, fmt.Errorf("rabbitmq exchange declare: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("rabbitmq confirm mode: %w", err)
+	}
+
+	// буфер с запасом: подтверждения читаются постоянно, но не должны блокировать библиотеку
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 256))
+	connClose := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClose := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	lost := make(chan struct{})
+	go func() {
+		var reason *amqp.Error
+		select {
+		case reason = <-connClose:
+		case reason = <-chClose:
+		}
+		// nil — соединение закрыто нами
+		if reason != nil {
+			logger.L.Error("rabbitmq.connection.lost", zap.String("reason", reason.Error()))
+		}
+		close(lost)
+	}()
+
+	r.mu.Lock()
+	r.conn, r.ch, r.seq = conn, ch, 0
+	r.pending = make(map[uint64]chan error)
+	r.mu.Unlock()
+
+	go r.readConfirms(ch, confirms)
+	return lost, nil
+}
+
+// readConfirms передаёт ack/nack ожидающим публикациям; завершается с закрытием канала
+func (r *Rabbit) readConfirms(ch *amqp.Channel, confirms <-chan amqp.Confirmation) {
+	for c := range confirms {
+		r.mu.Lock()
+		if r.ch != ch {
+			r.mu.Unlock()
+			continue
+		}
+		if res, ok := r.pending[c.DeliveryTag]; ok {
+			delete(r.pending, c.DeliveryTag)
+			if c.Ack {
+				res <- nil
+			} else {
+				res <- ErrRabbitNack
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// watch ждёт разрыва и переподключается, пока не вызван Close
+func (r *Rabbit) watch(lost <-chan struct{}) {
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.closing:
+			return
+		case <-lost:
+		}
+
+		r.drop(ErrRabbitUnavailable)
+
+		backoff := rabbitMinBackoff
+		for {
+			select {
+			case <-r.closing:
+				return
+			case <-time.After(backoff):
+			}
+
+			var err error
+			if lost, err = r.connect(); err == nil {
+				logger.L.Info("rabbitmq.reconnect.ok")
+				break
+			}
+			logger.L.Warn("rabbitmq.reconnect.failed", zap.Duration("backoff", backoff), zap.Error(err))
+			backoff = min(backoff*2, rabbitMaxBackoff)
+		}
+	}
+}
+
+// drop закрывает текущее соединение и завершает ожидающие публикации ошибкой err
+func (r *Rabbit) drop(err error) {
+	r.mu.Lock()
+	conn := r.conn
+	r.conn, r.ch = nil, nil
+	for tag, res := range r.pending {
+		res <- err
+		delete(r.pending, tag)
 	}
+	r.mu.Unlock()
 
-	return conn, ch, nil
+	// вне r.mu: закрытие ждёт библиотеку, которая может ждать readConfirms
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// Publish публикует сообщение и ждёт подтверждения брокера или отмены ctx
+func (r *Rabbit) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	res := make(chan error, 1)
+
+	r.mu.Lock()
+	ch := r.ch
+	if ch == nil {
+		r.mu.Unlock()
+		return ErrRabbitUnavailable
+	}
+	if err := ch.Publish(exchange, routingKey, false, false, msg); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	r.seq++
+	tag := r.seq
+	r.pending[tag] = res
+	r.mu.Unlock()
+
+	select {
+	case err := <-res:
+		return err
+	case <-ctx.Done():
+		r.mu.Lock()
+		// после переподключения теги начинаются заново и принадлежат другим сообщениям
+		if r.ch == ch {
+			delete(r.pending, tag)
+		}
+		r.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Close останавливает переподключение и закрывает соединение
+func (r *Rabbit) Close() {
+	select {
+	case <-r.closing:
+		return
+	default:
+	}
+	close(r.closing)
+	<-r.done
+	r.drop(ErrRabbitClosed)
 }
@@ -0,0 +1,104 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// RabbitChannel отдельное соединение с RabbitMQ для потребителя событий: объявление топологии,
+// чтение очереди и публикация с подтверждением брокера (перенос в очереди повторов и dead-letter).
+// В отличие от Rabbit не переподключается: после разрыва Consume закрывает канал доставок,
+// и вызывающий открывает новое соединение.
+type RabbitChannel struct {
+	conn *amqp.Connection
+	ch   *amqp.Channel
+
+	mu       sync.Mutex // публикации по одной: подтверждение читается сразу после отправки
+	seq      uint64
+	confirms chan amqp.Confirmation
+}
+
+// DialRabbitChannel открывает соединение и канал в режиме подтверждений
+func DialRabbitChannel(cfg *RabbitConfig) (*RabbitChannel, error) {
+	url := fmt.Sprintf("amqp://%s:%s@%s:%d/", cfg.User, cfg.Password, cfg.Host, cfg.Port)
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq connect: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("rabbitmq channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("rabbitmq confirm mode: %w", err)
+	}
+
+	return &RabbitChannel{
+		conn:     conn,
+		ch:       ch,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+	}, nil
+}
+
+// DeclareExchange объявляет durable exchange
+func (r *RabbitChannel) DeclareExchange(name, kind string) error {
+	return r.ch.ExchangeDeclare(name, kind, true, false, false, false, nil)
+}
+
+// DeclareQueue объявляет durable очередь с аргументами (x-message-ttl, x-dead-letter-*)
+func (r *RabbitChannel) DeclareQueue(name string, args amqp.Table) error {
+	_, err := r.ch.QueueDeclare(name, true, false, false, false, args)
+	return err
+}
+
+func (r *RabbitChannel) BindQueue(queue, routingKey, exchange string) error {
+	return r.ch.QueueBind(queue, routingKey, exchange, false, nil)
+}
+
+// Consume читает очередь с ручным подтверждением; prefetch ограничивает число неподтверждённых сообщений
+func (r *RabbitChannel) Consume(queue string, prefetch int) (<-chan amqp.Delivery, error) {
+	if err := r.ch.Qos(prefetch, 0, false); err != nil {
+		return nil, err
+	}
+	return r.ch.Consume(queue, "", false, false, false, false, nil)
+}
+
+// Publish публикует сообщение и ждёт подтверждения брокера или отмены ctx
+func (r *RabbitChannel) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.ch.Publish(exchange, routingKey, false, false, msg); err != nil {
+		return err
+	}
+	r.seq++
+
+	for {
+		select {
+		case c, ok := <-r.confirms:
+			if !ok {
+				return ErrRabbitUnavailable
+			}
+			// подтверждения публикаций, чей Publish уже вернул ошибку по ctx
+			if c.DeliveryTag < r.seq {
+				continue
+			}
+			if !c.Ack {
+				return ErrRabbitNack
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close закрывает соединение; неподтверждённые сообщения брокер вернёт в очередь
+func (r *RabbitChannel) Close() error {
+	return r.conn.Close()
+}
@@ -0,0 +1,187 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// unlockTimeout время на снятие блокировки после отмены контекста
+const unlockTimeout = 5 * time.Second
+
+// session соединение, на котором держится advisory lock (блокировка живёт, пока живо соединение)
+type session interface {
+	TryLock(ctx context.Context) (bool, error)
+	Ping(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// Close возвращает соединение; broken — соединение нельзя переиспользовать
+	Close(broken bool)
+}
+
+// Elector выбирает лидера среди реплик через pg_try_advisory_lock.
+// Лидер держит отдельное соединение из пула и каждые heartbeat проверяет, что оно живо;
+// остальные реплики с тем же периодом пытаются захватить блокировку.
+type Elector struct {
+	name      string
+	heartbeat time.Duration
+	connect   func(ctx context.Context) (session, error)
+	leader    atomic.Bool
+	metrics   *infra.Metrics
+}
+
+// New создаёт Elector для блокировки с именем name (ключ — hashtext(name))
+func New(pool *pgxpool.Pool, name string, heartbeat time.Duration) *Elector {
+	return &Elector{
+		name:      name,
+		heartbeat: heartbeat,
+		connect: func(ctx context.Context) (session, error) {
+			conn, err := pool.Acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &pgSession{conn: conn, name: name}, nil
+		},
+	}
+}
+
+// SetMetrics устанавливает метрики для Elector
+func (e *Elector) SetMetrics(metrics *infra.Metrics) {
+	e.metrics = metrics
+	e.setLeader(e.leader.Load())
+}
+
+// IsLeader сообщает, является ли экземпляр лидером
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run блокируется до отмены ctx. Пока экземпляр лидер, выполняется work;
+// её контекст отменяется при потере лидерства. После отмены ctx блокировка снимается.
+func (e *Elector) Run(ctx context.Context, work func(ctx context.Context)) {
+	ticker := time.NewTicker(e.heartbeat)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		e.lead(ctx, work)
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead пытается захватить блокировку и, если удалось, держит её, пока жива сессия, work и ctx
+func (e *Elector) lead(ctx context.Context, work func(ctx context.Context)) {
+	s, err := e.connect(ctx)
+	if err != nil {
+		logger.L.Warn("leader.connect.failed", zap.String("lock", e.name), zap.Error(err))
+		return
+	}
+	ok, err := s.TryLock(ctx)
+	if err != nil {
+		logger.L.Warn("leader.lock.failed", zap.String("lock", e.name), zap.Error(err))
+		s.Close(true)
+		return
+	}
+	if !ok {
+		s.Close(false)
+		return
+	}
+
+	e.setLeader(true)
+	logger.L.Info("leader.acquired", zap.String("lock", e.name))
+
+	workCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(workCtx)
+	}()
+
+	broken := e.hold(ctx, s, done)
+
+	cancel()
+	<-done
+	e.setLeader(false)
+
+	if !broken {
+		unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), unlockTimeout)
+		if err := s.Unlock(unlockCtx); err != nil {
+			logger.L.Warn("leader.unlock.failed", zap.String("lock", e.name), zap.Error(err))
+			broken = true
+		}
+		cancelUnlock()
+	}
+	s.Close(broken)
+	logger.L.Info("leader.released", zap.String("lock", e.name))
+}
+
+// hold проверяет сессию каждые heartbeat до отмены ctx или завершения work.
+// Возвращает true, если сессия потеряна.
+func (e *Elector) hold(ctx context.Context, s session, done <-chan struct{}) bool {
+	ticker := time.NewTicker(e.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-done:
+			return false
+		case <-ticker.C:
+			if err := s.Ping(ctx); err != nil {
+				if ctx.Err() != nil {
+					return false
+				}
+				logger.L.Error("leader.heartbeat.failed", zap.String("lock", e.name), zap.Error(err))
+				return true
+			}
+		}
+	}
+}
+
+func (e *Elector) setLeader(v bool) {
+	e.leader.Store(v)
+	if e.metrics != nil {
+		if v {
+			e.metrics.LeaderStatus.Set(1)
+		} else {
+			e.metrics.LeaderStatus.Set(0)
+		}
+	}
+}
+
+type pgSession struct {
+	conn *pgxpool.Conn
+	name string
+}
+
+func (s *pgSession) TryLock(ctx context.Context) (bool, error) {
+	var ok bool
+	err := s.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", s.name).Scan(&ok)
+	return ok, err
+}
+
+func (s *pgSession) Ping(ctx context.Context) error {
+	return s.conn.Ping(ctx)
+}
+
+func (s *pgSession) Unlock(ctx context.Context) error {
+	_, err := s.conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtext($1))", s.name)
+	return err
+}
+
+func (s *pgSession) Close(broken bool) {
+	if broken {
+		// закрытое соединение пул не вернёт в оборот, а вместе с ним PostgreSQL снимет блокировку
+		_ = s.conn.Conn().Close(context.Background())
+	}
+	s.conn.Release()
+}
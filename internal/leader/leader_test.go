@@ -0,0 +1,158 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+// fakeLock общая advisory-блокировка для нескольких сессий
+type fakeLock struct {
+	mu    sync.Mutex
+	owner *fakeSession
+}
+
+type fakeSession struct {
+	lock    *fakeLock
+	pingErr error
+	closed  bool
+	broken  bool
+}
+
+func (s *fakeSession) TryLock(_ context.Context) (bool, error) {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	if s.lock.owner != nil && s.lock.owner != s {
+		return false, nil
+	}
+	s.lock.owner = s
+	return true, nil
+}
+
+func (s *fakeSession) Ping(_ context.Context) error {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	return s.pingErr
+}
+
+func (s *fakeSession) Unlock(_ context.Context) error {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	if s.lock.owner == s {
+		s.lock.owner = nil
+	}
+	return nil
+}
+
+func (s *fakeSession) Close(broken bool) {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	s.closed, s.broken = true, broken
+	// как в PostgreSQL: закрытая сессия теряет блокировку
+	if broken && s.lock.owner == s {
+		s.lock.owner = nil
+	}
+}
+
+func newTestElector(lock *fakeLock) *Elector {
+	return &Elector{
+		name:      "test",
+		heartbeat: 5 * time.Millisecond,
+		connect: func(_ context.Context) (session, error) {
+			return &fakeSession{lock: lock}, nil
+		},
+	}
+}
+
+func TestElector_SingleLeader(t *testing.T) {
+	lock := &fakeLock{}
+	a, b := newTestElector(lock), newTestElector(lock)
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	workA := make(chan struct{})
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA, func(ctx context.Context) {
+			close(workA)
+			<-ctx.Done()
+		})
+		close(doneA)
+	}()
+	<-workA
+	assert.True(t, a.IsLeader())
+
+	workB := make(chan struct{})
+	go b.Run(ctxB, func(ctx context.Context) {
+		close(workB)
+		<-ctx.Done()
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, b.IsLeader(), "second replica must wait while the lock is held")
+
+	// отмена контекста лидера снимает блокировку, и её забирает другая реплика
+	cancelA()
+	<-doneA
+	assert.False(t, a.IsLeader())
+
+	select {
+	case <-workB:
+	case <-time.After(time.Second):
+		t.Fatal("second replica did not take over leadership")
+	}
+	assert.True(t, b.IsLeader())
+}
+
+func TestElector_HeartbeatFailure(t *testing.T) {
+	lock := &fakeLock{}
+	e := newTestElector(lock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{}, 2)
+	stopped := make(chan struct{}, 2)
+	go e.Run(ctx, func(ctx context.Context) {
+		started <- struct{}{}
+		<-ctx.Done()
+		stopped <- struct{}{}
+	})
+	<-started
+
+	lock.mu.Lock()
+	first := lock.owner
+	first.pingErr = errors.New("connection reset")
+	lock.mu.Unlock()
+
+	// потеря сессии останавливает работу, затем лидерство берётся заново на новом соединении
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("work was not stopped after heartbeat failure")
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("leadership was not re-acquired")
+	}
+
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	assert.True(t, first.closed)
+	assert.True(t, first.broken)
+	assert.NotSame(t, first, lock.owner)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ContextScopes ключ gin.Context со списком прав API ключа ([]string).
+// Выставляется только при аутентификации по ключу.
+const ContextScopes = "scopes"
+
+// APIKeyAuthenticator проверяет API ключ (реализуется service.APIKeyService).
+// Текст возвращаемой ошибки отдаётся клиенту как причина 401.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, raw string) (*model.APIKey, error)
+}
+
+// APIKeyMiddleware принимает ключ из X-API-Key или "Authorization: ApiKey <key>"
+// и выставляет те же ключи контекста, что и JWTMiddleware.
+// Запросы без ключа передаются в fallback (обычно JWTMiddleware); nil — такие запросы отклоняются.
+func APIKeyMiddleware(auth APIKeyAuthenticator, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := apiKeyFromRequest(c)
+		if raw == "" {
+			if fallback != nil {
+				fallback(c)
+				return
+			}
+			abortUnauthorized(c, "authorization required")
+			return
+		}
+
+		key, err := auth.Authenticate(c.Request.Context(), raw)
+		if err != nil {
+			logger.L.Debug("auth.apikey.rejected", zap.Error(err))
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		c.Set(ContextUserID, key.UserID)
+		c.Set(ContextRoles, []string(nil))
+		c.Set(ContextScopes, key.Scopes)
+		c.Next()
+	}
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, key, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
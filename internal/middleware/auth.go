@@ -1,35 +1,165 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/iokiris/efm-subscription-api/internal/logger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
-// JWTMiddleware
-// NOTE: заглушка.
-// Добавлено как возможность быстро накинуть авторизацию при необходимости.
-func JWTMiddleware() gin.HandlerFunc {
+const (
+	// ContextUserID ключ gin.Context, под которым лежит ID аутентифицированного пользователя
+	ContextUserID = "user_id"
+	// ContextRoles ключ gin.Context со списком ролей пользователя ([]string)
+	ContextRoles = "roles"
+)
+
+// JWTConfig настройки проверки токенов
+type JWTConfig struct {
+	// Secret общий ключ для HS256. Пустой — HS256 не принимается.
+	Secret []byte
+	// KeySet публичные ключи для RS256/ES256. nil — асимметричные алгоритмы не принимаются.
+	KeySet *KeySet
+	// Issuer/Audience проверяются, если заданы
+	Issuer   string
+	Audience string
+	// UserClaim claim, из которого берётся user_id (по умолчанию "sub")
+	UserClaim string
+	// RoleClaim claim с ролью: строка или массив строк (по умолчанию "role")
+	RoleClaim string
+	// Leeway допустимое расхождение часов при проверке exp/nbf
+	Leeway time.Duration
+}
+
+// JWTMiddleware проверяет Bearer токен (подпись, exp/nbf/iss/aud)
+// и кладёт user_id из UserClaim в контекст.
+// На каждую причину отказа отдаётся свой текст ошибки с кодом 401.
+func JWTMiddleware(cfg JWTConfig) gin.HandlerFunc {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "role"
+	}
+
+	var methods []string
+	if len(cfg.Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.KeySet != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	parser := jwt.NewParser(opts...)
+
 	return func(c *gin.Context) {
-		logger.L.Info("JWTMiddleware")
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
+			abortUnauthorized(c, "authorization required")
+			return
+		}
+		scheme, tokenStr, ok := strings.Cut(authHeader, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || tokenStr == "" {
+			abortUnauthorized(c, "invalid authorization scheme")
 			return
 		}
-		tokenStr := authHeader[len("Bearer "):]
 
-		// нет реализации
-		userID := tokenStr
+		claims := jwt.MapClaims{}
+		_, err := parser.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+			switch t.Method.(type) {
+			case *jwt.SigningMethodHMAC:
+				return cfg.Secret, nil
+			case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+				kid, _ := t.Header["kid"].(string)
+				return cfg.KeySet.Key(c.Request.Context(), kid)
+			default:
+				return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+			}
+		})
+		if err != nil {
+			logger.L.Debug("auth.jwt.rejected", zap.Error(err))
+			abortUnauthorized(c, jwtErrorReason(err))
+			return
+		}
 
+		userID, _ := claims[cfg.UserClaim].(string)
 		if userID == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			abortUnauthorized(c, "missing user claim")
+			return
+		}
+		// user_id в БД — uuid: иной идентификатор упал бы ошибкой Postgres и ответом 500
+		if _, err := uuid.Parse(userID); err != nil || len(userID) != len(uuid.Nil.String()) {
+			abortUnauthorized(c, "invalid user claim")
 			return
-
 		}
-		c.Set("user_id", userID)
+
+		c.Set(ContextUserID, userID)
+		c.Set(ContextRoles, claimStrings(claims[cfg.RoleClaim]))
 		c.Next()
 	}
 }
+
+// claimStrings приводит claim к списку строк: "a", "a b" и ["a", "b"] допустимы
+func claimStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.Fields(val)
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// jwtErrorReason переводит ошибку парсера в причину отказа для клиента
+func jwtErrorReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed token"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown signing key"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "invalid signature"
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "token not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "invalid issuer"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "invalid audience"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "missing required claim"
+	default:
+		return "invalid token"
+	}
+}
+
+func abortUnauthorized(c *gin.Context, reason string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+}
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+var testSecret = []byte("test-secret")
+
+func setupAuthRouter(cfg JWTConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me", JWTMiddleware(cfg), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString(ContextUserID)})
+	})
+	return r
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return s
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+	doc := map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+	return path
+}
+
+func TestJWTMiddleware_HS256(t *testing.T) {
+	now := time.Now()
+	cfg := JWTConfig{Secret: testSecret, Issuer: "efm", Audience: "subscriptions"}
+	valid := jwt.MapClaims{
+		"sub": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		"iss": "efm",
+		"aud": "subscriptions",
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	with := func(k string, v any) jwt.MapClaims {
+		c := jwt.MapClaims{}
+		for key, val := range valid {
+			c[key] = val
+		}
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "valid token",
+			header:         "Bearer " + signHS256(t, valid),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing header",
+			header:         "",
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "authorization required",
+		},
+		{
+			name:           "wrong scheme",
+			header:         "Basic dXNlcjpwYXNz",
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid authorization scheme",
+		},
+		{
+			name:           "malformed token",
+			header:         "Bearer not-a-jwt",
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "malformed token",
+		},
+		{
+			name:           "expired",
+			header:         "Bearer " + signHS256(t, with("exp", now.Add(-time.Hour).Unix())),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "token expired",
+		},
+		{
+			name:           "not valid yet",
+			header:         "Bearer " + signHS256(t, with("nbf", now.Add(time.Hour).Unix())),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "token not valid yet",
+		},
+		{
+			name:           "wrong issuer",
+			header:         "Bearer " + signHS256(t, with("iss", "other")),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid issuer",
+		},
+		{
+			name:           "wrong audience",
+			header:         "Bearer " + signHS256(t, with("aud", "other")),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid audience",
+		},
+		{
+			name:           "missing user claim",
+			header:         "Bearer " + signHS256(t, with("sub", nil)),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "missing user claim",
+		},
+		{
+			name:           "user claim is not a uuid",
+			header:         "Bearer " + signHS256(t, with("sub", "alice")),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid user claim",
+		},
+		{
+			name:           "user claim in non-canonical uuid form",
+			header:         "Bearer " + signHS256(t, with("sub", "urn:uuid:60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid user claim",
+		},
+		{
+			name:           "bad signature",
+			header:         "Bearer " + signHS256(t, valid) + "x",
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid signature",
+		},
+	}
+
+	router := setupAuthRouter(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]string
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, valid["sub"], response["user_id"])
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_RS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ks, err := LoadKeySet(context.Background(), writeJWKS(t, "k1", &key.PublicKey), time.Minute)
+	require.NoError(t, err)
+
+	router := setupAuthRouter(JWTConfig{KeySet: ks, UserClaim: "uid"})
+	const userID = "9b2e61f4-3c1a-4d8e-9f70-2a4b6c8d0e12"
+
+	sign := func(kid string) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"uid": userID,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		tok.Header["kid"] = kid
+		s, err := tok.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{name: "known kid", token: sign("k1"), expectedStatus: http.StatusOK},
+		{name: "unknown kid", token: sign("k2"), expectedStatus: http.StatusUnauthorized, expectedError: "unknown signing key"},
+		{name: "hs256 not configured", token: signHS256(t, jwt.MapClaims{"uid": userID, "exp": time.Now().Add(time.Hour).Unix()}), expectedStatus: http.StatusUnauthorized, expectedError: "invalid signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]string
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, userID, response["user_id"])
+			}
+		})
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	assert.Equal(t, []string{"admin"}, claimStrings("admin"))
+	assert.Equal(t, []string{"user", "admin"}, claimStrings("user admin"))
+	assert.Equal(t, []string{"user", "admin"}, claimStrings([]interface{}{"user", "admin", 1}))
+	assert.Nil(t, claimStrings(nil))
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// APIKey ключ доступа для машинных клиентов.
+// Сам секрет не хранится: только префикс для поиска и bcrypt-хеш.
+type APIKey struct {
+	ID         int64      `db:"id" json:"id"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	Hash       string     `db:"key_hash" json:"-"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	Name       string     `db:"name" json:"name"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
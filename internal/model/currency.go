@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency валюта подписок и сумм, если не указана
+const DefaultCurrency = "RUB"
+
+// RateDateLayout формат даты курса в API и CSV
+const RateDateLayout = DateLayout
+
+// ExchangeRate курс на дату: 1 Base = Rate Quote.
+// Курс действует с Date до следующего курса той же пары.
+type ExchangeRate struct {
+	Date  time.Time `json:"date" swaggertype:"string" example:"2025-07-01"`
+	Base  string    `json:"base" example:"USD"`
+	Quote string    `json:"quote" example:"RUB"`
+	Rate  float64   `json:"rate" example:"81.25"`
+}
+
+// MarshalJSON выводит дату курса без времени, в RateDateLayout
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	type plain ExchangeRate
+	return json.Marshal(struct {
+		plain
+		Date string `json:"date"`
+	}{plain(r), r.Date.Format(RateDateLayout)})
+}
+
+// UnmarshalJSON принимает дату курса в RateDateLayout
+func (r *ExchangeRate) UnmarshalJSON(data []byte) error {
+	type plain ExchangeRate
+	var in struct {
+		plain
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	date, err := time.Parse(RateDateLayout, in.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expect YYYY-MM-DD", in.Date)
+	}
+	*r = ExchangeRate(in.plain)
+	r.Date = date
+	return nil
+}
+
+// ExchangeRateFilter фильтр списка курсов. Пустые поля не фильтруют.
+type ExchangeRateFilter struct {
+	Base  string
+	Quote string
+}
+
+// NormalizeCurrency приводит код валюты ISO 4217 к верхнему регистру и проверяет формат
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency %q, expect ISO 4217 code", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency %q, expect ISO 4217 code", code)
+		}
+	}
+	return code, nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventLogEntry событие в журнале: конверт CloudEvents и куда он публиковался
+type EventLogEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	EventID    string          `db:"event_id" json:"event_id"`
+	Type       string          `db:"type" json:"type"`
+	Exchange   string          `db:"exchange" json:"exchange"`
+	RoutingKey string          `db:"routing_key" json:"routing_key"`
+	UserID     string          `db:"user_id" json:"user_id,omitempty"`
+	Subject    string          `db:"subject" json:"subject,omitempty"`
+	Payload    json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	OccurredAt time.Time       `db:"occurred_at" json:"occurred_at"`
+}
+
+// EventLogFilter отбор событий журнала. Пустые поля не ограничивают выборку;
+// AfterID — продолжить с события после него (возобновление прерванного replay).
+type EventLogFilter struct {
+	UserID  string     `json:"user_id,omitempty"`
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+	Types   []string   `json:"types,omitempty"`
+	AfterID int64      `json:"after_id,omitempty"`
+}
+
+// ReplayResult итог запуска replay: Matched — событий под фильтром, LastID — последнее из них
+type ReplayResult struct {
+	Matched int64 `json:"matched"`
+	LastID  int64 `json:"last_id"`
+	DryRun  bool  `json:"dry_run"`
+}
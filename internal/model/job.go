@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Статусы запуска фоновой задачи
+const (
+	JobRunning = "running"
+	JobOK      = "ok"
+	JobFailed  = "failed"
+)
+
+// JobRun запуск фоновой задачи; Processed — сколько подписок обработано
+type JobRun struct {
+	ID         int64      `db:"id" json:"id"`
+	Job        string     `db:"job" json:"job"`
+	Status     string     `db:"status" json:"status"`
+	Processed  int        `db:"processed" json:"processed"`
+	Error      string     `db:"error" json:"error,omitempty"`
+	StartedAt  time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
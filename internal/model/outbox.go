@@ -0,0 +1,16 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent событие, ожидающее публикации в брокер
+type OutboxEvent struct {
+	ID         int64           `db:"id" json:"id"`
+	Exchange   string          `db:"exchange" json:"exchange"`
+	RoutingKey string          `db:"routing_key" json:"routing_key"`
+	Payload    json.RawMessage `db:"payload" json:"payload"`
+	Attempts   int             `db:"attempts" json:"attempts"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
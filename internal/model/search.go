@@ -0,0 +1,7 @@
+package model
+
+// SubscriptionMatch подписка, найденная по имени сервиса; Score — сходство с запросом от 0 до 1
+type SubscriptionMatch struct {
+	Subscription Subscription `json:"subscription"`
+	Score        float64      `json:"score" example:"0.71"`
+}
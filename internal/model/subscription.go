@@ -2,22 +2,204 @@ package model
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 )
 
-// Subscription общая структура для подписок
+// Subscription общая структура для подписок.
+// Price — цена в Currency за один период оплаты (BillingInterval × BillingPeriod),
+// MonthlyCost — вычисляемая цена в пересчёте на месяц, Status — вычисляемое состояние на текущий месяц;
+// оба при записи игнорируются. Paused — подписка приостановлена сейчас (из subscription_pauses).
+// TrialEnd — первый платный день: списания до него бесплатны.
 
 type Subscription struct {
-	ID        int64      `db:"id" json:"id"`
-	Service   string     `db:"service_name" json:"service_name"`
-	Price     int        `db:"price" json:"price"`
-	UserID    string     `db:"user_id" json:"user_id"`
-	StartDate MonthYear  `db:"start_date" json:"start_date"`
-	EndDate   *MonthYear `db:"end_date,omitempty" json:"end_date,omitempty"`
-	CreatedAt time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	ID              int64         `db:"id" json:"id"`
+	Service         string        `db:"service_name" json:"service_name"`
+	Price           int           `db:"price" json:"price"`
+	Currency        string        `db:"currency" json:"currency" default:"RUB" example:"RUB"`
+	BillingPeriod   BillingPeriod `db:"billing_period" json:"billing_period" enums:"week,month,quarter,year" default:"month"`
+	BillingInterval int           `db:"billing_interval" json:"billing_interval" default:"1" minimum:"1"`
+	MonthlyCost     float64       `db:"-" json:"monthly_cost" readonly:"true"`
+	Status          Status        `db:"-" json:"status" enums:"active,paused,ended" readonly:"true"`
+	Paused          bool          `db:"paused" json:"-"`
+	UserID          string        `db:"user_id" json:"user_id"`
+	StartDate       MonthYear     `db:"start_date" json:"start_date"`
+	EndDate         *MonthYear    `db:"end_date,omitempty" json:"end_date,omitempty"`
+	TrialEnd        *Date         `db:"trial_end" json:"trial_end,omitempty" swaggertype:"string" example:"2025-08-15"`
+	CreatedAt       time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// MarshalJSON дополняет подписку вычисляемым monthly_cost
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type plain Subscription
+	p := plain(s)
+	p.MonthlyCost = s.NormalizedMonthlyCost()
+	p.Status = s.StatusAt(time.Now())
+	// указатель: MarshalJSON у MonthYear объявлен на *MonthYear
+	return json.Marshal(&p)
+}
+
+// StatusAt состояние подписки на момент at: ended после месяца end_date, paused при открытой паузе
+func (s Subscription) StatusAt(at time.Time) Status {
+	if s.EndDate != nil {
+		month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if time.Time(*s.EndDate).Before(month) {
+			return StatusEnded
+		}
+	}
+	if s.Paused {
+		return StatusPaused
+	}
+	return StatusActive
+}
+
+// NormalizedMonthlyCost цена в пересчёте на месяц с точностью до копеек
+func (s Subscription) NormalizedMonthlyCost() float64 {
+	interval := s.BillingInterval
+	if interval < 1 {
+		interval = 1
+	}
+	months := s.BillingPeriod.Months() * float64(interval)
+	return math.Round(float64(s.Price)/months*100) / 100
+}
+
+// Normalize подставляет значения по умолчанию (RUB, ежемесячно) и проверяет валюту, период оплаты и trial_end
+func (s *Subscription) Normalize() error {
+	if s.Currency == "" {
+		s.Currency = DefaultCurrency
+	}
+	currency, err := NormalizeCurrency(s.Currency)
+	if err != nil {
+		return err
+	}
+	s.Currency = currency
+
+	if s.BillingPeriod == "" {
+		s.BillingPeriod = BillingMonth
+	}
+	if s.BillingInterval == 0 {
+		s.BillingInterval = 1
+	}
+	if !s.BillingPeriod.Valid() {
+		return fmt.Errorf("invalid billing_period %q, expect week, month, quarter or year", s.BillingPeriod)
+	}
+	if s.BillingInterval < 1 {
+		return fmt.Errorf("invalid billing_interval %d, must be positive", s.BillingInterval)
+	}
+	if s.TrialEnd != nil && time.Time(*s.TrialEnd).Before(time.Time(s.StartDate)) {
+		return fmt.Errorf("trial_end must not precede start_date")
+	}
+	return nil
+}
+
+// BillingPeriod единица периода оплаты
+type BillingPeriod string
+
+const (
+	BillingWeek    BillingPeriod = "week"
+	BillingMonth   BillingPeriod = "month"
+	BillingQuarter BillingPeriod = "quarter"
+	BillingYear    BillingPeriod = "year"
+)
+
+// Valid true для известных периодов
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingWeek, BillingMonth, BillingQuarter, BillingYear:
+		return true
+	}
+	return false
+}
+
+// Months длительность периода в месяцах (неделя — 12/52 месяца)
+func (p BillingPeriod) Months() float64 {
+	switch p {
+	case BillingWeek:
+		return 12.0 / 52
+	case BillingQuarter:
+		return 3
+	case BillingYear:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// Status вычисляемое состояние подписки
+type Status string
+
+const (
+	StatusActive Status = "active"
+	StatusPaused Status = "paused"
+	StatusEnded  Status = "ended"
+)
+
+// Valid true для пустого и известных значений
+func (s Status) Valid() bool {
+	switch s {
+	case "", StatusActive, StatusPaused, StatusEnded:
+		return true
+	}
+	return false
+}
+
+// SubscriptionPause пауза подписки: месяцы с PausedFrom до ResumedFrom (не включая) не оплачиваются.
+// ResumedFrom == nil — пауза не завершена.
+type SubscriptionPause struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	PausedFrom     MonthYear  `json:"paused_from" swaggertype:"string" example:"07-2025"`
+	ResumedFrom    *MonthYear `json:"resumed_from,omitempty" swaggertype:"string" example:"09-2025"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// SubscriptionPrice цена подписки, действующая с EffectiveFrom до следующей записи истории
+type SubscriptionPrice struct {
+	EffectiveFrom MonthYear `json:"effective_from" swaggertype:"string" example:"07-2025"`
+	Price         int       `json:"price" example:"400"`
+}
+
+// RenewalDue наступившее очередное списание подписки (событие renewal_due)
+type RenewalDue struct {
+	Subscription Subscription `json:"subscription"`
+	DueOn        Date         `json:"due_on" swaggertype:"string" example:"2025-08-01"`
+}
+
+// SubscriptionFilter фильтр выборок подписок. Пустые поля не фильтруют.
+// ActiveOn — период подписки включает месяц этой даты (паузы не учитываются); Status — состояние на текущий месяц.
+type SubscriptionFilter struct {
+	UserID   string
+	Service  string
+	Trial    TrialState
+	ActiveOn *time.Time
+	PriceMin *int
+	PriceMax *int
+	Status   Status
+}
+
+// TrialState состояние пробного периода для фильтра списка
+type TrialState string
+
+const (
+	// TrialActive пробный период ещё идёт
+	TrialActive TrialState = "active"
+	// TrialConverted пробный период закончился, подписка платная
+	TrialConverted TrialState = "converted"
+	// TrialNone подписка без пробного периода
+	TrialNone TrialState = "none"
+)
+
+// Valid true для пустого и известных значений
+func (t TrialState) Valid() bool {
+	switch t {
+	case "", TrialActive, TrialConverted, TrialNone:
+		return true
+	}
+	return false
 }
 
 // MonthYear — кастомный тип, необходимый для передачи MM-YYYY в валидный формат time.Time
@@ -51,6 +233,41 @@ func (my *MonthYear) MarshalJSON() ([]byte, error) {
 	return marshalled, nil
 }
 
+// DateLayout формат Date в JSON
+const DateLayout = "2006-01-02"
+
+// Date дата без времени в формате YYYY-MM-DD
+type Date time.Time
+
+// MarshalJSON сериализует Date в строку "YYYY-MM-DD"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(d).Format(DateLayout) + `"`), nil
+}
+
+// UnmarshalJSON парсит Date из строки "YYYY-MM-DD"
+func (d *Date) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return fmt.Errorf("invalid date format, expect YYYY-MM-DD: %w", err)
+	}
+	*d = Date(t)
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return time.Time(d), nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	*d = Date(t)
+	return nil
+}
+
 func (my MonthYear) Value() (driver.Value, error) {
 	t := time.Time(my)
 	return t, nil // для совместимости с pgx
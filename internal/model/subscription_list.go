@@ -0,0 +1,122 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubscriptionListQuery параметры списка подписок пользователя как они пришли в запросе;
+// разбирает и проверяет SubscriptionService.List
+type SubscriptionListQuery struct {
+	UserID   string
+	Trial    TrialState
+	Service  string
+	ActiveOn string // YYYY-MM-DD или MM-YYYY
+	PriceMin string
+	PriceMax string
+	Status   string // active, paused, ended
+	Sort     string // created_at (по умолчанию), price, start_date, service_name
+	Order    string // asc, desc; по умолчанию desc для created_at, asc для остальных
+	Limit    string
+	Cursor   string
+}
+
+// SubscriptionSort поле сортировки списка подписок; при равных значениях порядок задаёт id
+type SubscriptionSort string
+
+const (
+	SortCreatedAt   SubscriptionSort = "created_at"
+	SortPrice       SubscriptionSort = "price"
+	SortStartDate   SubscriptionSort = "start_date"
+	SortServiceName SubscriptionSort = "service_name"
+)
+
+// Valid true для известных полей
+func (s SubscriptionSort) Valid() bool {
+	switch s {
+	case SortCreatedAt, SortPrice, SortStartDate, SortServiceName:
+		return true
+	}
+	return false
+}
+
+// SubscriptionPageRequest страница списка: Limit строк после After в порядке Sort/Desc
+type SubscriptionPageRequest struct {
+	Sort  SubscriptionSort
+	Desc  bool
+	Limit int
+	After *SubscriptionCursor // nil — первая страница
+}
+
+// SubscriptionPage страница списка подписок. Пустой NextCursor — страница последняя.
+type SubscriptionPage struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoicHJpY2UiLCJ2IjoiNDAwIiwiaWQiOjEyfQ"`
+}
+
+// SubscriptionCursor позиция в списке: значение поля сортировки и id последней строки страницы.
+// Сортировка входит в курсор, чтобы его нельзя было применить к другому порядку.
+type SubscriptionCursor struct {
+	Sort  SubscriptionSort `json:"s"`
+	Desc  bool             `json:"d,omitempty"`
+	Value string           `json:"v"`
+	ID    int64            `json:"id"`
+}
+
+// ErrInvalidCursor курсор повреждён или выдан не этим API
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorAfter курсор, указывающий на sub при сортировке sort
+func CursorAfter(sort SubscriptionSort, desc bool, sub Subscription) SubscriptionCursor {
+	c := SubscriptionCursor{Sort: sort, Desc: desc, ID: sub.ID}
+	switch sort {
+	case SortPrice:
+		c.Value = strconv.Itoa(sub.Price)
+	case SortStartDate:
+		c.Value = time.Time(sub.StartDate).Format(DateLayout)
+	case SortServiceName:
+		c.Value = sub.Service
+	default:
+		c.Value = sub.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// Encode непрозрачное представление курсора для клиента
+func (c SubscriptionCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseSubscriptionCursor разбирает курсор, полученный от Encode
+func ParseSubscriptionCursor(s string) (*SubscriptionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c SubscriptionCursor
+	if err := json.Unmarshal(data, &c); err != nil || !c.Sort.Valid() || c.ID <= 0 || !c.validValue() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// validValue Value имеет формат CursorAfter для Sort: иначе приведение типа в запросе упадёт в БД
+func (c SubscriptionCursor) validValue() bool {
+	var err error
+	switch c.Sort {
+	case SortPrice:
+		_, err = strconv.ParseInt(c.Value, 10, 32)
+	case SortStartDate:
+		_, err = time.Parse(DateLayout, c.Value)
+	case SortServiceName:
+		return !strings.ContainsRune(c.Value, 0)
+	default:
+		_, err = time.Parse(time.RFC3339Nano, c.Value)
+	}
+	return err == nil
+}
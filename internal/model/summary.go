@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Группировки для разбивки суммы
+const (
+	GroupByService = "service"
+	GroupByMonth   = "month"
+)
+
+// SummaryQuery параметры запроса суммы из API. Даты в формате MM-YYYY, пустые — без ограничения.
+// Пустой Currency — DefaultCurrency.
+type SummaryQuery struct {
+	UserID   string
+	Service  string
+	From     string
+	To       string
+	Currency string
+	GroupBy  []string
+}
+
+// SummaryFilter разобранные параметры суммы для репозитория
+type SummaryFilter struct {
+	UserID   string
+	Service  string
+	From     time.Time
+	To       time.Time
+	Currency string
+}
+
+// Summary сумма расходов по подпискам за период в валюте Currency.
+// Списания, для которых не нашлось курса, в суммы не входят и перечислены в MissingRates.
+type Summary struct {
+	Total        int               `json:"total"`
+	Currency     string            `json:"currency"`
+	Items        []SummaryItem     `json:"items"`
+	MissingRates []MissingRate     `json:"missing_rates,omitempty"`
+	Breakdown    *SummaryBreakdown `json:"breakdown,omitempty"`
+}
+
+// SummaryItem вклад одной подписки: цена × число списаний (дат оплаты) внутри периода.
+// Price — цена последнего списания в периоде в валюте подписки Currency, Cost — в валюте суммы.
+type SummaryItem struct {
+	SubscriptionID int64         `json:"subscription_id"`
+	Service        string        `json:"service_name"`
+	Price          int           `json:"price"`
+	Currency       string        `json:"currency"`
+	BillingPeriod  BillingPeriod `json:"billing_period"`
+	Charges        int           `json:"charges"`
+	Cost           int           `json:"cost"`
+}
+
+// MissingRate нет курса From → To, действующего в месяце Month
+type MissingRate struct {
+	Month MonthYear `json:"month" swaggertype:"string" example:"07-2025"`
+	From  string    `json:"from" example:"USD"`
+	To    string    `json:"to" example:"RUB"`
+}
+
+// SummaryBreakdown разбивка суммы по group_by
+type SummaryBreakdown struct {
+	ByService []ServiceTotal `json:"by_service,omitempty"`
+	ByMonth   []MonthTotal   `json:"by_month,omitempty"`
+}
+
+// ServiceTotal сумма по сервису. Series заполняется при group_by=service,month.
+type ServiceTotal struct {
+	Service string       `json:"service_name"`
+	Total   int          `json:"total"`
+	Series  []MonthTotal `json:"series,omitempty"`
+}
+
+// MonthTotal сумма за месяц
+type MonthTotal struct {
+	Month MonthYear `json:"month" swaggertype:"string" example:"07-2025"`
+	Total int       `json:"total"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки webhook
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook HTTP-подписка пользователя на события подписок.
+// Events — типы событий (subscription.created.v1), пустой — все.
+// Secret — ключ HMAC-подписи; наружу отдаётся только при создании.
+// FailureCount — неудачные попытки подряд; после порога webhook выключается (Enabled = false, DisabledAt).
+type Webhook struct {
+	ID           int64      `db:"id" json:"id"`
+	UserID       string     `db:"user_id" json:"user_id"`
+	URL          string     `db:"url" json:"url"`
+	Secret       string     `db:"secret" json:"-"`
+	Events       []string   `db:"events" json:"events"`
+	Enabled      bool       `db:"enabled" json:"enabled"`
+	FailureCount int        `db:"failure_count" json:"failure_count"`
+	DisabledAt   *time.Time `db:"disabled_at" json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery доставка события webhook; Payload — конверт CloudEvents
+type WebhookDelivery struct {
+	ID            int64           `db:"id" json:"id"`
+	WebhookID     int64           `db:"webhook_id" json:"webhook_id"`
+	EventID       string          `db:"event_id" json:"event_id"`
+	EventType     string          `db:"event_type" json:"event_type"`
+	Payload       json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Status        string          `db:"status" json:"status" enums:"pending,delivered,failed"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt попытка доставки: StatusCode — ответ получателя, Error — ошибка соединения или тело ответа
+type WebhookAttempt struct {
+	ID         int64     `db:"id" json:"id"`
+	DeliveryID int64     `db:"delivery_id" json:"delivery_id"`
+	Attempt    int       `db:"attempt" json:"attempt"`
+	StatusCode *int      `db:"status_code" json:"status_code,omitempty"`
+	Error      string    `db:"error" json:"error,omitempty"`
+	DurationMs int64     `db:"duration_ms" json:"duration_ms"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// WebhookDispatch доставка, взятая в отправку, с адресом и секретом webhook
+type WebhookDispatch struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+}
@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// Границы задержки повторной публикации
+const (
+	minBackoff = time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// Relay публикует события из outbox в брокер и отмечает их отправленными после подтверждения.
+// Неудачная публикация откладывается с экспоненциальной задержкой.
+// Реплики могут работать одновременно: ListPending пропускает строки, заблокированные другими.
+type Relay struct {
+	repo     repo.OutboxRepoInterface
+	pub      service.Publisher
+	interval time.Duration
+	batch    int
+}
+
+func NewRelay(r repo.OutboxRepoInterface, pub service.Publisher, interval time.Duration, batch int) *Relay {
+	return &Relay{repo: r, pub: pub, interval: interval, batch: batch}
+}
+
+// Run блокируется до отмены ctx. Полностью обработанный батч выбирается сразу же снова, иначе — через interval.
+func (r *Relay) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	logger.L.Info("outbox.relay.started", zap.Duration("interval", r.interval), zap.Int("batch", r.batch))
+	for {
+		select {
+		case <-ctx.Done():
+			logger.L.Info("outbox.relay.stopped")
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.L.Error("outbox.relay.failed", zap.Error(err))
+		}
+		if err == nil && n == r.batch {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.interval)
+		}
+	}
+}
+
+// RelayOnce публикует один батч и возвращает число обработанных событий.
+// Строки заблокированы, пока идёт транзакция, а Publish ждёт брокер до EVENTS_PUBLISH_TIMEOUT,
+// поэтому батч останавливается на первой ошибке публикации или отмене ctx;
+// оставшиеся строки освобождаются при коммите и выбираются следующим вызовом.
+// Транзакция не зависит от отмены ctx: иначе коммит не выполнится, и отметки уже
+// подтверждённых брокером событий откатятся, а сами события будут опубликованы повторно.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	var n int
+	err := r.repo.InTx(context.WithoutCancel(ctx), func(txCtx context.Context) error {
+		events, err := r.repo.ListPending(txCtx, r.batch)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if ctx.Err() != nil {
+				return nil
+			}
+			n++
+			if pubErr := r.pub.Publish(e.Exchange, e.RoutingKey, e.Payload); pubErr != nil {
+				next := time.Now().Add(backoff(e.Attempts + 1))
+				if err := r.repo.MarkFailed(txCtx, e.ID, pubErr, next); err != nil {
+					return err
+				}
+				logger.L.Warn("outbox.publish.failed",
+					zap.Int64("id", e.ID),
+					zap.String("routing_key", e.RoutingKey),
+					zap.Int("attempt", e.Attempts+1),
+					zap.Time("next_attempt", next),
+					zap.Int("skipped", len(events)-n),
+					zap.Error(pubErr))
+				return nil
+			}
+			if err := r.repo.MarkSent(txCtx, e.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// backoff задержка перед попыткой attempt (с 1): minBackoff, удваиваясь, но не больше maxBackoff
+func backoff(attempt int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+type mockOutboxRepo struct {
+	mock.Mock
+}
+
+// InTx как pgx.BeginFunc: с отменённым ctx коммит не выполняется, и транзакция откатывается
+func (m *mockOutboxRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (m *mockOutboxRepo) Add(ctx context.Context, e *model.OutboxEvent) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *mockOutboxRepo) ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]model.OutboxEvent), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockOutboxRepo) MarkFailed(ctx context.Context, id int64, cause error, nextAttempt time.Time) error {
+	return m.Called(ctx, id, cause, nextAttempt).Error(0)
+}
+
+func (m *mockOutboxRepo) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type mockPublisher struct {
+	mock.Mock
+}
+
+func (m *mockPublisher) Publish(exchange, routingKey string, body []byte) error {
+	return m.Called(exchange, routingKey, body).Error(0)
+}
+
+func (m *mockPublisher) PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error {
+	return m.Called(exchange, routingKey, body, headers).Error(0)
+}
+
+func (m *mockPublisher) Close(context.Context) int { return 0 }
+
+func TestRelay_RelayOnce(t *testing.T) {
+	ctx := context.Background()
+	events := []model.OutboxEvent{
+		{ID: 1, Exchange: "subscriptions", RoutingKey: "created", Payload: []byte(`{"id":1}`)},
+		{ID: 2, Exchange: "subscriptions", RoutingKey: "updated", Payload: []byte(`{"id":2}`), Attempts: 3},
+	}
+	brokerDown := errors.New("publisher queue full")
+
+	repo := new(mockOutboxRepo)
+	pub := new(mockPublisher)
+	repo.On("ListPending", mock.Anything, 10).Return(events, nil)
+	pub.On("Publish", "subscriptions", "created", []byte(`{"id":1}`)).Return(nil)
+	pub.On("Publish", "subscriptions", "updated", []byte(`{"id":2}`)).Return(brokerDown)
+	repo.On("MarkSent", mock.Anything, int64(1)).Return(nil)
+
+	start := time.Now()
+	// четвёртая попытка откладывается на 8 секунд
+	repo.On("MarkFailed", mock.Anything, int64(2), brokerDown, mock.MatchedBy(func(next time.Time) bool {
+		d := next.Sub(start)
+		return d >= 8*time.Second && d < 9*time.Second
+	})).Return(nil)
+
+	n, err := NewRelay(repo, pub, time.Second, 10).RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+	pub.AssertExpectations(t)
+}
+
+func TestRelay_RelayOnce_StopsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	events := []model.OutboxEvent{
+		{ID: 1, Exchange: "subscriptions", RoutingKey: "created", Payload: []byte(`{"id":1}`)},
+		{ID: 2, Exchange: "subscriptions", RoutingKey: "updated", Payload: []byte(`{"id":2}`)},
+		{ID: 3, Exchange: "subscriptions", RoutingKey: "deleted", Payload: []byte(`{"id":3}`)},
+	}
+	brokerDown := errors.New("publish timeout")
+
+	repo := new(mockOutboxRepo)
+	pub := new(mockPublisher)
+	repo.On("ListPending", mock.Anything, 10).Return(events, nil)
+	pub.On("Publish", "subscriptions", "created", []byte(`{"id":1}`)).Return(brokerDown)
+	repo.On("MarkFailed", mock.Anything, int64(1), brokerDown, mock.Anything).Return(nil)
+
+	n, err := NewRelay(repo, pub, time.Second, 10).RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	// остальные строки не трогаются и освобождаются коммитом
+	pub.AssertNumberOfCalls(t, "Publish", 1)
+	repo.AssertNotCalled(t, "MarkFailed", mock.Anything, int64(2), mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "MarkSent", mock.Anything, mock.Anything)
+}
+
+func TestRelay_RelayOnce_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := []model.OutboxEvent{
+		{ID: 1, Exchange: "subscriptions", RoutingKey: "created", Payload: []byte(`{"id":1}`)},
+		{ID: 2, Exchange: "subscriptions", RoutingKey: "updated", Payload: []byte(`{"id":2}`)},
+	}
+
+	repo := new(mockOutboxRepo)
+	pub := new(mockPublisher)
+	repo.On("ListPending", mock.Anything, 10).Return(events, nil)
+	// остановка приходит, пока брокер подтверждает первое событие
+	pub.On("Publish", "subscriptions", "created", []byte(`{"id":1}`)).Run(func(mock.Arguments) { cancel() }).Return(nil)
+	repo.On("MarkSent", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), int64(1)).Return(nil)
+
+	n, err := NewRelay(repo, pub, time.Second, 10).RelayOnce(ctx)
+	// транзакция закоммичена: подтверждённое событие остаётся отправленным
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	repo.AssertExpectations(t)
+	pub.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{9, 256 * time.Second},
+		{10, maxBackoff},
+		{100, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoff(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
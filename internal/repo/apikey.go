@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrAPIKeyNotFound ключ не найден (или принадлежит другому пользователю)
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepoInterface интерфейс для репозитория API ключей
+type APIKeyRepoInterface interface {
+	Create(ctx context.Context, k *model.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id int64, userID string) error
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+type APIKeyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepo(db *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+const apiKeyColumns = `id, prefix, key_hash, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*model.APIKey, error) {
+	var k model.APIKey
+	err := row.Scan(
+		&k.ID, &k.Prefix, &k.Hash, &k.UserID, &k.Name, &k.Scopes,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *model.APIKey) error {
+	const q = `
+        INSERT INTO api_keys (prefix, key_hash, user_id, name, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+	return r.db.QueryRow(ctx, q,
+		k.Prefix, k.Hash, k.UserID, k.Name, k.Scopes, k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *APIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	q := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	return scanAPIKey(r.db.QueryRow(ctx, q, prefix))
+}
+
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	q := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke отзывает ключ пользователя. Повторный отзыв не меняет revoked_at.
+func (r *APIKeyRepo) Revoke(ctx context.Context, id int64, userID string) error {
+	const q = `
+        UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+        WHERE id = $1 AND user_id = $2
+    `
+	ct, err := r.db.Exec(ctx, q, id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed обновляет last_used_at не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id int64) error {
+	const q = `
+        UPDATE api_keys SET last_used_at = NOW()
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+    `
+	_, err := r.db.Exec(ctx, q, id)
+	return err
+}
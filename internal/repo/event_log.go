@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventLogRepoInterface интерфейс для журнала событий
+type EventLogRepoInterface interface {
+	Add(ctx context.Context, e *model.EventLogEntry) error
+	Stats(ctx context.Context, f model.EventLogFilter) (count, lastID int64, err error)
+	List(ctx context.Context, f model.EventLogFilter, limit int) ([]model.EventLogEntry, error)
+	TryLockReplay(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+// replayLock имя advisory lock replay (ключ — hashtext)
+const replayLock = "events-replay"
+
+// unlockTimeout время на снятие advisory lock
+const unlockTimeout = 5 * time.Second
+
+type EventLogRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewEventLogRepo(db *pgxpool.Pool) *EventLogRepo {
+	return &EventLogRepo{db: db}
+}
+
+// Add записывает событие; внутри InTx — в той же транзакции, что и изменение данных
+func (r *EventLogRepo) Add(ctx context.Context, e *model.EventLogEntry) error {
+	const q = `
+        INSERT INTO event_log (event_id, type, exchange, routing_key, user_id, subject, payload)
+        VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, $7)
+        RETURNING id, occurred_at
+    `
+	return conn(ctx, r.db).QueryRow(ctx, q,
+		e.EventID, e.Type, e.Exchange, e.RoutingKey, e.UserID, e.Subject, e.Payload,
+	).Scan(&e.ID, &e.OccurredAt)
+}
+
+// Stats число событий под фильтром и id последнего из них (0 — нет событий)
+func (r *EventLogRepo) Stats(ctx context.Context, f model.EventLogFilter) (int64, int64, error) {
+	where, args := eventLogWhere(f)
+	q := `SELECT COUNT(*), COALESCE(MAX(id), 0) FROM event_log` + where
+	var count, lastID int64
+	err := conn(ctx, r.db).QueryRow(ctx, q, args...).Scan(&count, &lastID)
+	return count, lastID, err
+}
+
+// List до limit событий под фильтром в порядке записи, начиная после f.AfterID
+func (r *EventLogRepo) List(ctx context.Context, f model.EventLogFilter, limit int) ([]model.EventLogEntry, error) {
+	where, args := eventLogWhere(f)
+	args = append(args, limit)
+	q := fmt.Sprintf(`
+        SELECT id, event_id, type, exchange, routing_key, COALESCE(user_id::text, ''), subject, payload, occurred_at
+        FROM event_log%s
+        ORDER BY id
+        LIMIT $%d
+    `, where, len(args))
+
+	rows, err := conn(ctx, r.db).Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.EventLogEntry
+	for rows.Next() {
+		var e model.EventLogEntry
+		if err := rows.Scan(&e.ID, &e.EventID, &e.Type, &e.Exchange, &e.RoutingKey, &e.UserID, &e.Subject, &e.Payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// TryLockReplay захватывает advisory lock replay, общий для всех реплик. Блокировка держится на отдельном
+// соединении из пула до вызова unlock; false — replay уже идёт на другой реплике.
+func (r *EventLogRepo) TryLockReplay(ctx context.Context) (func(), bool, error) {
+	c, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := c.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", replayLock).Scan(&ok); err != nil || !ok {
+		c.Release()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+		if _, err := c.Exec(ctx, "SELECT pg_advisory_unlock(hashtext($1))", replayLock); err != nil {
+			// закрытое соединение пул не вернёт в оборот, а вместе с ним PostgreSQL снимет блокировку
+			_ = c.Conn().Close(ctx)
+		}
+		c.Release()
+	}
+	return unlock, true, nil
+}
+
+// eventLogWhere условие WHERE по фильтру и его аргументы
+func eventLogWhere(f model.EventLogFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.AfterID > 0 {
+		add("id > $%d", f.AfterID)
+	}
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	if len(f.Types) > 0 {
+		add("type = ANY($%d)", f.Types)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExchangeRateRepoInterface интерфейс для репозитория курсов валют
+type ExchangeRateRepoInterface interface {
+	Upsert(ctx context.Context, rates []model.ExchangeRate) error
+	List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error)
+}
+
+type ExchangeRateRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRateRepo(db *pgxpool.Pool) *ExchangeRateRepo {
+	return &ExchangeRateRepo{db: db}
+}
+
+// Upsert сохраняет курсы одной транзакцией; курс на ту же дату и пару перезаписывается
+func (r *ExchangeRateRepo) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	const q = `
+        INSERT INTO exchange_rates (rate_date, base, quote, rate)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (base, quote, rate_date) DO UPDATE SET rate = EXCLUDED.rate
+    `
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, rate := range rates {
+			batch.Queue(q, rate.Date, rate.Base, rate.Quote, rate.Rate)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+func (r *ExchangeRateRepo) List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	const q = `
+        SELECT rate_date, base, quote, rate::float8
+        FROM exchange_rates
+        WHERE ($1::text = '' OR base = $1)
+          AND ($2::text = '' OR quote = $2)
+        ORDER BY base, quote, rate_date DESC
+    `
+	rows, err := r.db.Query(ctx, q, f.Base, f.Quote)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []model.ExchangeRate{}
+	for rows.Next() {
+		var rate model.ExchangeRate
+		if err := rows.Scan(&rate.Date, &rate.Base, &rate.Quote, &rate.Rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// JobRunRepoInterface интерфейс для журнала запусков фоновых задач
+type JobRunRepoInterface interface {
+	Start(ctx context.Context, job string) (int64, error)
+	Finish(ctx context.Context, id int64, processed int, runErr error) error
+}
+
+type JobRunRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewJobRunRepo(db *pgxpool.Pool) *JobRunRepo {
+	return &JobRunRepo{db: db}
+}
+
+// Start записывает начало запуска задачи job и возвращает его id
+func (r *JobRunRepo) Start(ctx context.Context, job string) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO job_runs (job, status) VALUES ($1, $2) RETURNING id", job, model.JobRunning,
+	).Scan(&id)
+	return id, err
+}
+
+// Finish завершает запуск: статус ok или failed с текстом runErr
+func (r *JobRunRepo) Finish(ctx context.Context, id int64, processed int, runErr error) error {
+	status, msg := model.JobOK, (*string)(nil)
+	if runErr != nil {
+		status = model.JobFailed
+		s := runErr.Error()
+		msg = &s
+	}
+	_, err := r.db.Exec(ctx,
+		"UPDATE job_runs SET status = $2, processed = $3, error = $4, finished_at = NOW() WHERE id = $1",
+		id, status, processed, msg,
+	)
+	return err
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OutboxRepoInterface интерфейс для таблицы outbox
+type OutboxRepoInterface interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Add(ctx context.Context, e *model.OutboxEvent) error
+	ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, cause error, nextAttempt time.Time) error
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepo(db *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// InTx выполняет fn в транзакции (см. repo.InTx)
+func (r *OutboxRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return InTx(ctx, r.db, fn)
+}
+
+// conn транзакция из ctx или пул
+func (r *OutboxRepo) conn(ctx context.Context) DBTX {
+	return conn(ctx, r.db)
+}
+
+// Add записывает событие; внутри InTx — в той же транзакции, что и изменение данных
+func (r *OutboxRepo) Add(ctx context.Context, e *model.OutboxEvent) error {
+	const q = `
+        INSERT INTO outbox (exchange, routing_key, payload)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+	return r.conn(ctx).QueryRow(ctx, q, e.Exchange, e.RoutingKey, e.Payload).Scan(&e.ID, &e.CreatedAt)
+}
+
+// ListPending возвращает до limit неотправленных событий, время повтора которых наступило, в порядке записи.
+// Вызывается внутри InTx: строки блокируются до конца транзакции, и другие реплики их пропускают.
+func (r *OutboxRepo) ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	const q = `
+        SELECT id, exchange, routing_key, payload, attempts, created_at
+        FROM outbox
+        WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `
+	rows, err := r.conn(ctx).Query(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		var e model.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Exchange, &e.RoutingKey, &e.Payload, &e.Attempts, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// MarkSent отмечает событие опубликованным
+func (r *OutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE outbox SET sent_at = NOW(), last_error = NULL WHERE id = $1", id)
+	return err
+}
+
+// MarkFailed увеличивает число попыток и откладывает следующую до nextAttempt
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, cause error, nextAttempt time.Time) error {
+	const q = `
+        UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+        WHERE id = $1
+    `
+	_, err := r.conn(ctx).Exec(ctx, q, id, cause.Error(), nextAttempt)
+	return err
+}
+
+// DeleteSent удаляет события, опубликованные раньше before, и возвращает их число
+func (r *OutboxRepo) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := r.conn(ctx).Exec(ctx, "DELETE FROM outbox WHERE sent_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
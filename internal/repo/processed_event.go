@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ProcessedEventRepoInterface интерфейс для таблицы обработанных событий (идемпотентность потребителя)
+type ProcessedEventRepoInterface interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Claim(ctx context.Context, consumer, eventID string) (bool, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type ProcessedEventRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewProcessedEventRepo(db *pgxpool.Pool) *ProcessedEventRepo {
+	return &ProcessedEventRepo{db: db}
+}
+
+// InTx выполняет fn в транзакции (см. repo.InTx)
+func (r *ProcessedEventRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return InTx(ctx, r.db, fn)
+}
+
+// Claim отмечает событие обработанным; false — уже обработано.
+// Внутри InTx отметка откатывается вместе с ошибкой обработчика, а параллельная доставка
+// того же события ждёт на первичном ключе до конца транзакции.
+func (r *ProcessedEventRepo) Claim(ctx context.Context, consumer, eventID string) (bool, error) {
+	const q = `
+        INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2)
+        ON CONFLICT (consumer, event_id) DO NOTHING
+    `
+	ct, err := conn(ctx, r.db).Exec(ctx, q, consumer, eventID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// DeleteBefore удаляет отметки старше before: повтор настолько старого события уже не ожидается
+func (r *ProcessedEventRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM processed_events WHERE processed_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
@@ -2,22 +2,56 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/iokiris/efm-subscription-api/internal/model"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	// ErrNotFound подписка не найдена (или принадлежит другому пользователю)
+	ErrNotFound = errors.New("subscription not found")
+	// ErrAlreadyPaused у подписки уже есть незавершённая пауза
+	ErrAlreadyPaused = errors.New("subscription is already paused")
+	// ErrNotPaused у подписки нет незавершённой паузы
+	ErrNotPaused = errors.New("subscription is not paused")
+)
+
 // SubscriptionRepoInterface интерфейс для репозитория подписок
 type SubscriptionRepoInterface interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 	GetByID(ctx context.Context, id int64) (*model.Subscription, error)
+	GetByIDForUser(ctx context.Context, id int64, userID string) (*model.Subscription, error)
+	GetForUpdate(ctx context.Context, id int64, userID string) (*model.Subscription, error)
 	Create(ctx context.Context, s *model.Subscription) error
 	Update(ctx context.Context, s *model.Subscription) error
+	UpdateForUser(ctx context.Context, s *model.Subscription, userID string) error
 	Delete(ctx context.Context, id int64) error
-	List(ctx context.Context, userID string) ([]model.Subscription, error)
-	GetSummary(ctx context.Context, userID, service string, from, to time.Time) (int, error)
+	DeleteForUser(ctx context.Context, id int64, userID string) error
+	List(ctx context.Context, f model.SubscriptionFilter, p model.SubscriptionPageRequest) ([]model.Subscription, error)
+	ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error)
+	Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error)
+	SuggestServices(ctx context.Context, query string, limit int) ([]string, error)
+	AddPrice(ctx context.Context, id int64, effectiveFrom time.Time, price int) error
+	ListTrialsEnding(ctx context.Context, until time.Time) ([]model.Subscription, error)
+	MarkTrialNotified(ctx context.Context, id int64) error
+	ListExpired(ctx context.Context) ([]model.Subscription, error)
+	MarkExpired(ctx context.Context, id int64) error
+	ListRenewalsDue(ctx context.Context) ([]model.RenewalDue, error)
+	MarkRenewalNotified(ctx context.Context, id int64, dueOn time.Time) error
+	OpenPause(ctx context.Context, id int64) (*model.SubscriptionPause, error)
+	Pause(ctx context.Context, id int64, from time.Time) (*model.SubscriptionPause, error)
+	Resume(ctx context.Context, pauseID int64, from time.Time) (*model.SubscriptionPause, error)
+	ListPrices(ctx context.Context, id int64) ([]model.SubscriptionPrice, error)
+	GetSummary(ctx context.Context, f model.SummaryFilter) (*model.Summary, error)
+	SummaryByService(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error)
+	SummaryByMonth(ctx context.Context, f model.SummaryFilter) ([]model.MonthTotal, error)
+	SummaryByServiceMonth(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error)
 }
 
 type SubscriptionRepo struct {
@@ -28,99 +62,698 @@ func NewSubscriptionRepo(db *pgxpool.Pool) *SubscriptionRepo {
 	return &SubscriptionRepo{db: db}
 }
 
-func (r *SubscriptionRepo) GetByID(ctx context.Context, id int64) (*model.Subscription, error) {
-	const q = `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-		FROM subscriptions
-		WHERE id = $1
-	`
+// InTx выполняет fn в транзакции (см. repo.InTx)
+func (r *SubscriptionRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return InTx(ctx, r.db, fn)
+}
+
+// conn транзакция из ctx или пул
+func (r *SubscriptionRepo) conn(ctx context.Context) DBTX {
+	return conn(ctx, r.db)
+}
+
+// subscriptionColumns колонки для SELECT ... FROM subscriptions; paused — есть пауза, действующая сегодня
+const subscriptionColumns = `id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, trial_end, created_at, updated_at,
+	` + pausedNowSQL + ` AS paused`
+
+// pausedNowSQL у подписки есть пауза, действующая сегодня
+const pausedNowSQL = `EXISTS (
+		SELECT 1 FROM subscription_pauses p
+		WHERE p.subscription_id = subscriptions.id
+		  AND p.paused_from <= CURRENT_DATE
+		  AND (p.resumed_from IS NULL OR p.resumed_from > CURRENT_DATE)
+	)`
+
+// scanSubscription сканирует subscriptionColumns; extra — колонки, выбранные после них
+func scanSubscription(row pgx.Row, extra ...any) (*model.Subscription, error) {
 	var s model.Subscription
-	err := r.db.QueryRow(ctx, q, id).Scan(
-		&s.ID, &s.Service, &s.Price, &s.UserID,
-		&s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt,
-	)
+	dest := []any{
+		&s.ID, &s.Service, &s.Price, &s.Currency, &s.BillingPeriod, &s.BillingInterval, &s.UserID,
+		&s.StartDate, &s.EndDate, &s.TrialEnd, &s.CreatedAt, &s.UpdatedAt, &s.Paused,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &s, nil
 }
 
+func (r *SubscriptionRepo) GetByID(ctx context.Context, id int64) (*model.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+	return scanSubscription(r.conn(ctx).QueryRow(ctx, q, id))
+}
+
+// GetByIDForUser возвращает подписку, только если она принадлежит userID
+func (r *SubscriptionRepo) GetByIDForUser(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND user_id = $2`
+	return scanSubscription(r.conn(ctx).QueryRow(ctx, q, id, userID))
+}
+
+// GetForUpdate возвращает подписку и блокирует строку до конца транзакции; вызывается внутри InTx.
+// Непустой userID ограничивает выборку подписками этого пользователя.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	return scanSubscription(r.conn(ctx).QueryRow(ctx, getForUpdateSQL, id, userID))
+}
+
+// getForUpdateSQL $2 типизирован как text (сравнение с пустой строкой), поэтому с uuid сравнивается после явного приведения
+const getForUpdateSQL = `SELECT ` + subscriptionColumns + ` FROM subscriptions
+	WHERE id = $1 AND ($2::text = '' OR user_id = NULLIF($2::text, '')::uuid) FOR UPDATE`
+
+// Create сохраняет подписку и начальную цену в истории цен (действует с start_date)
 func (r *SubscriptionRepo) Create(ctx context.Context, s *model.Subscription) error {
 	const q = `
-        INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id, created_at, updated_at
+        WITH sub AS (
+            INSERT INTO subscriptions (service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, trial_end)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            RETURNING id, price, start_date, created_at, updated_at
+        ), price AS (
+            INSERT INTO subscription_prices (subscription_id, effective_from, price)
+            SELECT id, start_date, price FROM sub
+        )
+        SELECT id, created_at, updated_at FROM sub
     `
-	return r.db.QueryRow(ctx, q,
-		s.Service, s.Price, s.UserID, s.StartDate, s.EndDate,
+	return r.conn(ctx).QueryRow(ctx, q,
+		s.Service, s.Price, s.Currency, s.BillingPeriod, s.BillingInterval, s.UserID, s.StartDate, s.EndDate, s.TrialEnd,
 	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
 }
 
 func (r *SubscriptionRepo) Update(ctx context.Context, s *model.Subscription) error {
 	const q = `
         UPDATE subscriptions
-        SET service_name=$1, price=$2, start_date=$3, end_date=$4, updated_at=NOW()
-        WHERE id=$5
+        SET service_name=$1, price=$2, currency=$3, billing_period=$4, billing_interval=$5, start_date=$6,
+            expired_at = CASE WHEN end_date IS DISTINCT FROM $7 THEN NULL ELSE expired_at END,
+            end_date=$7,
+            trial_notified_at = CASE WHEN trial_end IS DISTINCT FROM $8 THEN NULL ELSE trial_notified_at END,
+            trial_end=$8, updated_at=NOW()
+        WHERE id=$9
+        RETURNING updated_at
+    `
+	err := r.conn(ctx).QueryRow(ctx, q,
+		s.Service, s.Price, s.Currency, s.BillingPeriod, s.BillingInterval, s.StartDate, s.EndDate, s.TrialEnd, s.ID,
+	).Scan(&s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// UpdateForUser обновляет подписку, только если она принадлежит userID
+func (r *SubscriptionRepo) UpdateForUser(ctx context.Context, s *model.Subscription, userID string) error {
+	const q = `
+        UPDATE subscriptions
+        SET service_name=$1, price=$2, currency=$3, billing_period=$4, billing_interval=$5, start_date=$6,
+            expired_at = CASE WHEN end_date IS DISTINCT FROM $7 THEN NULL ELSE expired_at END,
+            end_date=$7,
+            trial_notified_at = CASE WHEN trial_end IS DISTINCT FROM $8 THEN NULL ELSE trial_notified_at END,
+            trial_end=$8, updated_at=NOW()
+        WHERE id=$9 AND user_id=$10
         RETURNING updated_at
     `
-	return r.db.QueryRow(ctx, q,
-		s.Service, s.Price, s.StartDate, s.EndDate, s.ID,
+	err := r.conn(ctx).QueryRow(ctx, q,
+		s.Service, s.Price, s.Currency, s.BillingPeriod, s.BillingInterval, s.StartDate, s.EndDate, s.TrialEnd, s.ID, userID,
 	).Scan(&s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
 }
 
 func (r *SubscriptionRepo) Delete(ctx context.Context, id int64) error {
-	ct, err := r.db.Exec(ctx, "DELETE FROM subscriptions WHERE id=$1", id)
+	ct, err := r.conn(ctx).Exec(ctx, "DELETE FROM subscriptions WHERE id=$1", id)
 	if err != nil {
 		return err
 	}
 	if ct.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+		return ErrNotFound
 	}
 	return nil
 }
 
-func (r *SubscriptionRepo) List(ctx context.Context, userID string) ([]model.Subscription, error) {
-	const q = `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+// DeleteForUser удаляет подписку, только если она принадлежит userID
+func (r *SubscriptionRepo) DeleteForUser(ctx context.Context, id int64, userID string) error {
+	ct, err := r.conn(ctx).Exec(ctx, "DELETE FROM subscriptions WHERE id=$1 AND user_id=$2", id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List возвращает до p.Limit подписок пользователя f.UserID с фильтрами f после курсора p.After
+func (r *SubscriptionRepo) List(ctx context.Context, f model.SubscriptionFilter, p model.SubscriptionPageRequest) ([]model.Subscription, error) {
+	key, ok := subscriptionSortKeys[p.Sort]
+	if !ok {
+		key = subscriptionSortKeys[model.SortCreatedAt]
+	}
+	dir, cmp := "ASC", ">"
+	if p.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	// keyset: (поле, id) после курсора; порядок совпадает с индексами idx_subscriptions_user_*
+	args := append(subscriptionFilterArgs(f), p.Limit)
+	q := `SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE user_id = $1 AND ` + subscriptionFilterSQL
+	if p.After != nil {
+		args = append(args, p.After.Value, p.After.ID)
+		q += fmt.Sprintf(`
+          AND (%s, id) %s ($9::%s, $10)`, key.column, cmp, key.cast)
+	}
+	q += fmt.Sprintf(`
+        ORDER BY %s %s, id %s
+        LIMIT $8`, key.column, dir, dir)
+
+	rows, err := r.conn(ctx).Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectSubscriptions(rows)
+}
+
+// subscriptionSortKeys колонка сортировки и тип значения курсора (model.CursorAfter)
+var subscriptionSortKeys = map[model.SubscriptionSort]struct{ column, cast string }{
+	model.SortCreatedAt:   {"created_at", "timestamptz"},
+	model.SortPrice:       {"price", "int"},
+	model.SortStartDate:   {"start_date", "date"},
+	model.SortServiceName: {"service_name", "text"},
+}
+
+// ListAll возвращает подписки всех пользователей с необязательными фильтрами (для администраторов)
+func (r *SubscriptionRepo) ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + `
         FROM subscriptions
-        WHERE user_id=$1
+        WHERE ($1::text = '' OR user_id = NULLIF($1::text, '')::uuid) AND ` + subscriptionFilterSQL + `
         ORDER BY created_at DESC
 	`
-	rows, err := r.db.Query(ctx, q, userID)
+	rows, err := r.conn(ctx).Query(ctx, q, subscriptionFilterArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	return collectSubscriptions(rows)
+}
+
+// serviceNameMatchSQL имя сервиса похоже на запрос $2 целиком (%) или на его часть (<%, для ввода по буквам).
+// Оба оператора pg_trgm используют idx_subscriptions_service_name_trgm; пороги — pg_trgm.*similarity_threshold.
+const serviceNameMatchSQL = `(service_name % $2 OR $2 <% service_name)`
+
+// serviceNameScoreSQL сходство имени сервиса с запросом $2 от 0 до 1
+const serviceNameScoreSQL = `GREATEST(similarity(service_name, $2), word_similarity($2, service_name))`
+
+// Search возвращает до limit подписок userID, имя сервиса которых похоже на query, наиболее похожие первыми
+func (r *SubscriptionRepo) Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error) {
+	q := `SELECT ` + subscriptionColumns + `, ` + serviceNameScoreSQL + ` AS score
+        FROM subscriptions
+        WHERE user_id = $1 AND ` + serviceNameMatchSQL + `
+        ORDER BY score DESC, id
+        LIMIT $3
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, userID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []model.SubscriptionMatch{}
+	for rows.Next() {
+		var score float64
+		s, err := scanSubscription(rows, &score)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, model.SubscriptionMatch{Subscription: *s, Score: score})
+	}
+	return matches, rows.Err()
+}
+
+// SuggestServices возвращает до limit имён сервисов из подписок всех пользователей, похожих на query:
+// сначала начинающиеся с query, затем по сходству и числу подписок
+func (r *SubscriptionRepo) SuggestServices(ctx context.Context, query string, limit int) ([]string, error) {
+	const q = `
+        SELECT service_name
+        FROM subscriptions
+        WHERE ` + serviceNameMatchSQL + `
+        GROUP BY service_name
+        ORDER BY starts_with(lower(service_name), lower($2)) DESC,
+                 ` + serviceNameScoreSQL + ` DESC,
+                 COUNT(*) DESC,
+                 service_name
+        LIMIT $1
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, limit, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// subscriptionFilterSQL условия SubscriptionFilter кроме пользователя; аргументы $1..$7 — subscriptionFilterArgs.
+// Состояние (status) считается на текущий месяц, как model.Subscription.StatusAt.
+const subscriptionFilterSQL = `($2::text = '' OR service_name = $2)
+          AND ($3::text = ''
+               OR ($3 = 'active' AND trial_end > CURRENT_DATE)
+               OR ($3 = 'converted' AND trial_end <= CURRENT_DATE)
+               OR ($3 = 'none' AND trial_end IS NULL))
+          AND ($4::date IS NULL
+               OR (start_date <= $4 AND (end_date IS NULL OR end_date >= date_trunc('month', $4::date))))
+          AND ($5::int IS NULL OR price >= $5)
+          AND ($6::int IS NULL OR price <= $6)
+          AND ($7::text = ''
+               OR ($7 = 'ended' AND end_date < date_trunc('month', CURRENT_DATE))
+               OR ($7 = 'paused' AND (end_date IS NULL OR end_date >= date_trunc('month', CURRENT_DATE)) AND ` + pausedNowSQL + `)
+               OR ($7 = 'active' AND (end_date IS NULL OR end_date >= date_trunc('month', CURRENT_DATE)) AND NOT ` + pausedNowSQL + `))`
+
+// subscriptionFilterArgs аргументы $1..$7 для subscriptionFilterSQL
+func subscriptionFilterArgs(f model.SubscriptionFilter) []any {
+	return []any{f.UserID, f.Service, f.Trial, f.ActiveOn, f.PriceMin, f.PriceMax, f.Status}
+}
+
+// ListTrialsEnding возвращает подписки, пробный период которых закончится не позже until
+// и о которых ещё не отправлено уведомление
+func (r *SubscriptionRepo) ListTrialsEnding(ctx context.Context, until time.Time) ([]model.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE trial_end >= CURRENT_DATE AND trial_end <= $1 AND trial_notified_at IS NULL
+        ORDER BY trial_end
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, until)
+	if err != nil {
+		return nil, err
+	}
+	return collectSubscriptions(rows)
+}
+
+// MarkTrialNotified отмечает, что уведомление о конце пробного периода отправлено
+func (r *SubscriptionRepo) MarkTrialNotified(ctx context.Context, id int64) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE subscriptions SET trial_notified_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// ListExpired возвращает подписки, end_date которых прошёл (месяц end_date закончился),
+// ещё не отмеченные как истёкшие
+func (r *SubscriptionRepo) ListExpired(ctx context.Context) ([]model.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE end_date + interval '1 month' <= CURRENT_DATE AND expired_at IS NULL
+        ORDER BY end_date
+	`
+	rows, err := r.conn(ctx).Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return collectSubscriptions(rows)
+}
+
+// MarkExpired отмечает, что событие expired отправлено
+func (r *SubscriptionRepo) MarkExpired(ctx context.Context, id int64) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE subscriptions SET expired_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// ListRenewalsDue возвращает подписки, у которых наступила дата очередного списания (кроме первого),
+// а renewal_due о нём ещё не отправлен. Списания в пробном периоде, внутри паузы и после end_date не учитываются.
+func (r *SubscriptionRepo) ListRenewalsDue(ctx context.Context) ([]model.RenewalDue, error) {
+	q := `SELECT ` + subscriptionColumns + `, due.due_on
+        FROM subscriptions
+        CROSS JOIN LATERAL (
+            SELECT MAX(d)::date AS due_on
+            FROM generate_series(start_date::timestamp, CURRENT_DATE::timestamp, ` + billingStepSQL + `) AS d
+        ) AS due
+        WHERE due.due_on > start_date
+          AND (end_date IS NULL OR due.due_on < end_date + interval '1 month')
+          AND (trial_end IS NULL OR due.due_on >= trial_end)
+          AND (renewal_notified_on IS NULL OR renewal_notified_on < due.due_on)
+          AND NOT EXISTS (
+              SELECT 1 FROM subscription_pauses p
+              WHERE p.subscription_id = subscriptions.id
+                AND due.due_on >= p.paused_from
+                AND (p.resumed_from IS NULL OR due.due_on < p.resumed_from)
+          )
+        ORDER BY due.due_on
+	`
+	rows, err := r.conn(ctx).Query(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var due []model.RenewalDue
+	for rows.Next() {
+		var dueOn model.Date
+		s, err := scanSubscription(rows, &dueOn)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, model.RenewalDue{Subscription: *s, DueOn: dueOn})
+	}
+	return due, rows.Err()
+}
+
+// MarkRenewalNotified отмечает, что renewal_due о списании dueOn отправлен
+func (r *SubscriptionRepo) MarkRenewalNotified(ctx context.Context, id int64, dueOn time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE subscriptions SET renewal_notified_on = $2 WHERE id = $1", id, dueOn)
+	return err
+}
+
+// AddPrice добавляет цену в историю с effectiveFrom; цена с той же датой перезаписывается
+func (r *SubscriptionRepo) AddPrice(ctx context.Context, id int64, effectiveFrom time.Time, price int) error {
+	const q = `
+        INSERT INTO subscription_prices (subscription_id, effective_from, price)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price
+    `
+	_, err := r.conn(ctx).Exec(ctx, q, id, effectiveFrom, price)
+	return err
+}
+
+// ListPrices возвращает историю цен подписки по возрастанию effective_from
+func (r *SubscriptionRepo) ListPrices(ctx context.Context, id int64) ([]model.SubscriptionPrice, error) {
+	const q = `
+        SELECT effective_from, price
+        FROM subscription_prices
+        WHERE subscription_id = $1
+        ORDER BY effective_from
+    `
+	rows, err := r.conn(ctx).Query(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []model.SubscriptionPrice{}
+	for rows.Next() {
+		var p model.SubscriptionPrice
+		if err := rows.Scan(&p.EffectiveFrom, &p.Price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+// uniqueViolation код ошибки PostgreSQL unique_violation
+const uniqueViolation = "23505"
+
+const pauseColumns = `id, subscription_id, paused_from, resumed_from, created_at`
+
+func scanPause(row pgx.Row) (*model.SubscriptionPause, error) {
+	var p model.SubscriptionPause
+	err := row.Scan(&p.ID, &p.SubscriptionID, &p.PausedFrom, &p.ResumedFrom, &p.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotPaused
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// OpenPause возвращает незавершённую паузу подписки или ErrNotPaused
+func (r *SubscriptionRepo) OpenPause(ctx context.Context, id int64) (*model.SubscriptionPause, error) {
+	q := `SELECT ` + pauseColumns + ` FROM subscription_pauses WHERE subscription_id = $1 AND resumed_from IS NULL`
+	return scanPause(r.conn(ctx).QueryRow(ctx, q, id))
+}
+
+// Pause открывает паузу с месяца from. Вторая незавершённая пауза запрещена уникальным индексом.
+func (r *SubscriptionRepo) Pause(ctx context.Context, id int64, from time.Time) (*model.SubscriptionPause, error) {
+	q := `INSERT INTO subscription_pauses (subscription_id, paused_from) VALUES ($1, $2) RETURNING ` + pauseColumns
+	p, err := scanPause(r.conn(ctx).QueryRow(ctx, q, id, from))
+	if pgErr := (*pgconn.PgError)(nil); errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, ErrAlreadyPaused
+	}
+	return p, err
+}
+
+// Resume завершает паузу: подписка снова оплачивается с месяца from
+func (r *SubscriptionRepo) Resume(ctx context.Context, pauseID int64, from time.Time) (*model.SubscriptionPause, error) {
+	q := `UPDATE subscription_pauses SET resumed_from = $2 WHERE id = $1 AND resumed_from IS NULL RETURNING ` + pauseColumns
+	return scanPause(r.conn(ctx).QueryRow(ctx, q, pauseID, from))
+}
+
+func collectSubscriptions(rows pgx.Rows) ([]model.Subscription, error) {
+	defer rows.Close()
+
 	var subs []model.Subscription
 	for rows.Next() {
-		var s model.Subscription
-		if err := rows.Scan(
-			&s.ID, &s.Service, &s.Price, &s.UserID,
-			&s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt,
-		); err != nil {
+		s, err := scanSubscription(rows)
+		if err != nil {
 			return nil, err
 		}
-		subs = append(subs, s)
+		subs = append(subs, *s)
 	}
 	return subs, rows.Err()
 }
 
-// GetSummary возвращает сумму цен по подпискам пользователя за указанный период.
-// Даты from/to могут быть в формате YYYY-MM (например, "05-2025") или RFC3339.
-// Пустые from/to означают весь период.
-// Подписки учитываются только если они пересекают интервал [from; to]:
+// billingStepSQL интервал между датами оплаты подписки
+const billingStepSQL = `CASE billing_period
+				WHEN 'week' THEN interval '1 week'
+				WHEN 'quarter' THEN interval '3 months'
+				WHEN 'year' THEN interval '1 year'
+				ELSE interval '1 month'
+			END * billing_interval`
+
+// chargesCTE разворачивает подписки в списания по датам оплаты внутри [$3; конец месяца $4]:
+// первое списание в start_date, далее каждые billing_interval × billing_period,
+// последнее — не позже конца месяца end_date. month — месяц списания для помесячных разбивок.
+// price — цена из subscription_prices, действующая на дату списания (без истории — текущая цена подписки).
+// Списания внутри пауз (subscription_pauses, [paused_from; resumed_from)) не учитываются,
+// списания до trial_end (пробный период) бесплатны.
+//
+// amount — сумма списания в валюте $5 по курсу, действующему в месяце списания
+// (последний курс прямой или обратной пары с датой до конца месяца); NULL — курса нет.
+// Параметры: $1 — user_id (пусто — все пользователи), $2 — service_name (пусто — все), $3/$4 — from/to,
+// $5 — валюта суммы.
+const chargesCTE = `
+	raw_charges AS (
+		SELECT s.id, s.service_name, s.currency, s.billing_period,
+		       CASE WHEN d < s.trial_end THEN 0 ELSE COALESCE((
+		           SELECT sp.price FROM subscription_prices sp
+		           WHERE sp.subscription_id = s.id AND sp.effective_from <= d
+		           ORDER BY sp.effective_from DESC
+		           LIMIT 1
+		       ), s.price) END AS price,
+		       d::date AS charged_on, date_trunc('month', d)::date AS month
+		FROM subscriptions s
+		CROSS JOIN LATERAL generate_series(
+			s.start_date::timestamp,
+			LEAST(COALESCE(s.end_date, $4::date), $4::date)::timestamp + interval '1 month' - interval '1 day',
+			` + billingStepSQL + `
+		) AS d
+		WHERE ($1::text = '' OR s.user_id = NULLIF($1::text, '')::uuid)
+		  AND ($2::text = '' OR s.service_name = $2)
+		  AND s.start_date <= $4
+		  AND (s.end_date IS NULL OR s.end_date >= $3)
+		  AND d >= $3::date
+		  AND NOT EXISTS (
+		      SELECT 1 FROM subscription_pauses p
+		      WHERE p.subscription_id = s.id
+		        AND d >= p.paused_from
+		        AND (p.resumed_from IS NULL OR d < p.resumed_from)
+		  )
+	),
+	charges AS (
+		SELECT c.*, c.price * CASE
+			WHEN c.currency = $5::text THEN 1::numeric
+			ELSE (
+				SELECT x.rate FROM (
+					SELECT rate_date, rate FROM exchange_rates
+					WHERE base = c.currency AND quote = $5::text AND rate_date < c.month + interval '1 month'
+					UNION ALL
+					SELECT rate_date, 1 / rate FROM exchange_rates
+					WHERE base = $5::text AND quote = c.currency AND rate_date < c.month + interval '1 month'
+				) x
+				ORDER BY x.rate_date DESC
+				LIMIT 1
+			)
+		END AS amount
+		FROM raw_charges c
+	)`
+
+// chargeMonthsCTE непрерывный ряд месяцев от первого списания до to: месяцы без списаний дают нули в графике.
+// Используется после chargesCTE.
+const chargeMonthsCTE = `
+	months AS (
+		SELECT gs::date AS month
+		FROM generate_series(
+			(SELECT MIN(month) FROM charges)::timestamp,
+			date_trunc('month', $4::date::timestamp),
+			interval '1 month'
+		) AS gs
+	)`
+
+func summaryArgs(f model.SummaryFilter) []any {
+	return []any{f.UserID, f.Service, f.From, f.To, f.Currency}
+}
+
+// GetSummary возвращает расходы по подпискам за указанный период в валюте f.Currency:
+// каждая подписка учитывается как цена × число её дат оплаты внутри [from; to].
+// Списания без курса не входят в суммы и возвращаются в MissingRates.
+// Пустой UserID — все пользователи.
 // NOTE: кеширование через Redis по ключу userID:service:from-to.
-func (r *SubscriptionRepo) GetSummary(ctx context.Context, userID, service string, from, to time.Time) (int, error) {
-	const q = `SELECT COALESCE(SUM(price), 0)
-	FROM subscriptions
-	WHERE user_id = $1
-	  AND ($2 = '' OR service_name = $2)
-	  AND start_date <= $4
-	  AND (end_date IS NULL AND start_date <= $4   -- активная подписка пересекает интервал
-		   OR end_date >= $3)                      -- завершённая подписка пересекает интервал
+func (r *SubscriptionRepo) GetSummary(ctx context.Context, f model.SummaryFilter) (*model.Summary, error) {
+	q := `WITH ` + chargesCTE + `
+	SELECT id, service_name, (array_agg(price ORDER BY charged_on DESC))[1] AS price, currency, billing_period,
+	       COUNT(*)::int AS charges, ROUND(SUM(amount))::bigint AS cost
+	FROM charges
+	WHERE amount IS NOT NULL
+	GROUP BY id, service_name, currency, billing_period
+	ORDER BY id
 	`
-	var total int
-	err := r.db.QueryRow(ctx, q, userID, service, from, to).Scan(&total)
-	return total, err
+	rows, err := r.conn(ctx).Query(ctx, q, summaryArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &model.Summary{Currency: f.Currency, Items: []model.SummaryItem{}}
+	for rows.Next() {
+		var it model.SummaryItem
+		if err := rows.Scan(&it.SubscriptionID, &it.Service, &it.Price, &it.Currency, &it.BillingPeriod, &it.Charges, &it.Cost); err != nil {
+			return nil, err
+		}
+		summary.Total += it.Cost
+		summary.Items = append(summary.Items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summary.MissingRates, err = r.missingRates(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// missingRates возвращает месяцы и валюты списаний, для которых нет курса в f.Currency
+func (r *SubscriptionRepo) missingRates(ctx context.Context, f model.SummaryFilter) ([]model.MissingRate, error) {
+	q := `WITH ` + chargesCTE + `
+	SELECT DISTINCT month, currency
+	FROM charges
+	WHERE amount IS NULL
+	ORDER BY month, currency
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, summaryArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var missing []model.MissingRate
+	for rows.Next() {
+		m := model.MissingRate{To: f.Currency}
+		if err := rows.Scan(&m.Month, &m.From); err != nil {
+			return nil, err
+		}
+		missing = append(missing, m)
+	}
+	return missing, rows.Err()
+}
+
+// SummaryByService возвращает сумму по каждому сервису, по убыванию суммы
+func (r *SubscriptionRepo) SummaryByService(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error) {
+	q := `WITH ` + chargesCTE + `
+	SELECT service_name, ROUND(SUM(amount))::bigint AS total
+	FROM charges
+	WHERE amount IS NOT NULL
+	GROUP BY service_name
+	ORDER BY total DESC, service_name
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, summaryArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []model.ServiceTotal{}
+	for rows.Next() {
+		var t model.ServiceTotal
+		if err := rows.Scan(&t.Service, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// SummaryByMonth возвращает помесячный ряд сумм от первого списания до to, включая месяцы без списаний
+func (r *SubscriptionRepo) SummaryByMonth(ctx context.Context, f model.SummaryFilter) ([]model.MonthTotal, error) {
+	q := `WITH ` + chargesCTE + `,` + chargeMonthsCTE + `
+	SELECT m.month, ROUND(COALESCE(SUM(c.amount), 0))::bigint AS total
+	FROM months m
+	LEFT JOIN charges c ON c.month = m.month
+	GROUP BY m.month
+	ORDER BY m.month
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, summaryArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []model.MonthTotal{}
+	for rows.Next() {
+		var t model.MonthTotal
+		if err := rows.Scan(&t.Month, &t.Total); err != nil {
+			return nil, err
+		}
+		series = append(series, t)
+	}
+	return series, rows.Err()
+}
+
+// SummaryByServiceMonth возвращает помесячный ряд по каждому сервису (общая ось месяцев, пропуски — нули).
+// Total сервиса — сумма его ряда; сервисы по убыванию суммы.
+func (r *SubscriptionRepo) SummaryByServiceMonth(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error) {
+	q := `WITH ` + chargesCTE + `,` + chargeMonthsCTE + `,
+	services AS (
+		SELECT service_name, SUM(amount) AS total FROM charges WHERE amount IS NOT NULL GROUP BY service_name
+	)
+	SELECT sv.service_name, m.month, ROUND(COALESCE(SUM(c.amount), 0))::bigint AS total
+	FROM services sv
+	CROSS JOIN months m
+	LEFT JOIN charges c ON c.service_name = sv.service_name AND c.month = m.month
+	GROUP BY sv.service_name, sv.total, m.month
+	ORDER BY sv.total DESC, sv.service_name, m.month
+	`
+	rows, err := r.conn(ctx).Query(ctx, q, summaryArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []model.ServiceTotal{}
+	for rows.Next() {
+		var (
+			service string
+			point   model.MonthTotal
+		)
+		if err := rows.Scan(&service, &point.Month, &point.Total); err != nil {
+			return nil, err
+		}
+
+		// строки отсортированы по сервису, поэтому ряд текущего сервиса всегда последний
+		if n := len(totals); n == 0 || totals[n-1].Service != service {
+			totals = append(totals, model.ServiceTotal{Service: service})
+		}
+		cur := &totals[len(totals)-1]
+		cur.Total += point.Total
+		cur.Series = append(cur.Series, point)
+	}
+	return totals, rows.Err()
 }
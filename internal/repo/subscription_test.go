@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSubscriptionRepo репозиторий на БД с применёнными migrations (DB_* как у сервера; в CI — сервис postgres).
+// Без DB_HOST тест пропускается.
+func testSubscriptionRepo(t *testing.T) *SubscriptionRepo {
+	t.Helper()
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		t.Skip("DB_HOST is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := NewPostgresPool(ctx, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), host, os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+	require.NoError(t, err)
+	require.NoError(t, pool.Ping(ctx))
+	t.Cleanup(pool.Close)
+	return NewSubscriptionRepo(pool)
+}
+
+func TestSubscriptionRepo_GetForUpdate(t *testing.T) {
+	r := testSubscriptionRepo(t)
+	ctx := context.Background()
+
+	const owner = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	sub := &model.Subscription{
+		Service:         "Netflix",
+		Price:           400,
+		Currency:        model.DefaultCurrency,
+		BillingPeriod:   model.BillingMonth,
+		BillingInterval: 1,
+		UserID:          owner,
+		StartDate:       model.MonthYear(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	require.NoError(t, r.Create(ctx, sub))
+	t.Cleanup(func() { _ = r.Delete(context.Background(), sub.ID) })
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr error
+	}{
+		{"owner", owner, nil},
+		{"any user", "", nil},
+		{"other user", "a3b1c2d4-0000-4000-8000-000000000001", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.InTx(ctx, func(ctx context.Context) error {
+				got, err := r.GetForUpdate(ctx, sub.ID, tt.userID)
+				if err == nil {
+					assert.Equal(t, sub.ID, got.ID)
+				}
+				return err
+			})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
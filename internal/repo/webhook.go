@@ -0,0 +1,308 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrWebhookNotFound webhook не найден (или принадлежит другому пользователю)
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrDeliveryNotFound доставка не найдена у этого webhook
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookRepoInterface интерфейс для webhooks, их доставок и попыток
+type WebhookRepoInterface interface {
+	Create(ctx context.Context, w *model.Webhook) error
+	Get(ctx context.Context, id int64, userID string) (*model.Webhook, error)
+	ListByUser(ctx context.Context, userID string) ([]model.Webhook, error)
+	Update(ctx context.Context, w *model.Webhook) error
+	Delete(ctx context.Context, id int64, userID string) error
+
+	Enqueue(ctx context.Context, userID, eventType, eventID string, payload []byte) (int64, error)
+	ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.WebhookDispatch, error)
+	AddAttempt(ctx context.Context, a *model.WebhookAttempt) error
+	FinishAttempt(ctx context.Context, deliveryID int64, status string, nextAttempt time.Time) error
+	ResetFailures(ctx context.Context, webhookID int64) error
+	AddFailure(ctx context.Context, webhookID int64, disableAfter int) (disabled bool, err error)
+	ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, webhookID, deliveryID int64) ([]model.WebhookAttempt, error)
+}
+
+type WebhookRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepo(db *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+// conn транзакция из ctx или пул
+func (r *WebhookRepo) conn(ctx context.Context) DBTX {
+	return conn(ctx, r.db)
+}
+
+const webhookColumns = `id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at`
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(
+		&w.ID, &w.UserID, &w.URL, &w.Secret, &w.Events, &w.Enabled,
+		&w.FailureCount, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func deliveryFields(d *model.WebhookDelivery) []any {
+	return []any{
+		&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status,
+		&d.Attempts, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt,
+	}
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, w *model.Webhook) error {
+	const q = `
+        INSERT INTO webhooks (user_id, url, secret, events)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + webhookColumns
+	created, err := scanWebhook(r.conn(ctx).QueryRow(ctx, q, w.UserID, w.URL, w.Secret, w.Events))
+	if err != nil {
+		return err
+	}
+	*w = *created
+	return nil
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, id int64, userID string) (*model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+	return scanWebhook(r.conn(ctx).QueryRow(ctx, q, id, userID))
+}
+
+func (r *WebhookRepo) ListByUser(ctx context.Context, userID string) ([]model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY id`
+	rows, err := r.conn(ctx).Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []model.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *w)
+	}
+	return hooks, rows.Err()
+}
+
+// Update меняет url, events и enabled. Повторное включение сбрасывает счётчик неудач.
+func (r *WebhookRepo) Update(ctx context.Context, w *model.Webhook) error {
+	const q = `
+        UPDATE webhooks SET
+            url = $3,
+            events = $4,
+            enabled = $5,
+            failure_count = CASE WHEN $5 AND NOT enabled THEN 0 ELSE failure_count END,
+            disabled_at = CASE WHEN $5 THEN NULL ELSE COALESCE(disabled_at, NOW()) END,
+            updated_at = NOW()
+        WHERE id = $1 AND user_id = $2
+        RETURNING ` + webhookColumns
+	updated, err := scanWebhook(r.conn(ctx).QueryRow(ctx, q, w.ID, w.UserID, w.URL, w.Events, w.Enabled))
+	if err != nil {
+		return err
+	}
+	*w = *updated
+	return nil
+}
+
+// Delete удаляет webhook вместе с историей доставок
+func (r *WebhookRepo) Delete(ctx context.Context, id int64, userID string) error {
+	ct, err := r.conn(ctx).Exec(ctx, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Enqueue создаёт доставки события во все включённые webhooks пользователя, подписанные на eventType.
+// Внутри InTx — в той же транзакции, что и изменение подписки. Возвращает число доставок.
+func (r *WebhookRepo) Enqueue(ctx context.Context, userID, eventType, eventID string, payload []byte) (int64, error) {
+	const q = `
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+        SELECT id, $3, $2, $4
+        FROM webhooks
+        WHERE user_id = $1 AND enabled AND (cardinality(events) = 0 OR $2 = ANY(events))
+    `
+	ct, err := r.conn(ctx).Exec(ctx, q, userID, eventType, eventID, payload)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// ClaimDue берёт в отправку до limit доставок, время которых наступило, и откладывает их до leaseUntil.
+// HTTP-запросы идут вне транзакции: если обработчик не завершит попытку, доставку после leaseUntil
+// возьмёт другая реплика.
+func (r *WebhookRepo) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.WebhookDispatch, error) {
+	const q = `
+        WITH due AS (
+            SELECT d.id
+            FROM webhook_deliveries d
+            JOIN webhooks w ON w.id = d.webhook_id
+            WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.enabled
+            ORDER BY d.next_attempt_at, d.id
+            LIMIT $1
+            FOR UPDATE OF d SKIP LOCKED
+        )
+        UPDATE webhook_deliveries d SET next_attempt_at = $2
+        FROM due, webhooks w
+        WHERE d.id = due.id AND w.id = d.webhook_id
+        RETURNING ` + deliveryColumns + `, w.url, w.secret
+    `
+	rows, err := r.conn(ctx).Query(ctx, q, limit, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []model.WebhookDispatch
+	for rows.Next() {
+		var w model.WebhookDispatch
+		if err := rows.Scan(append(deliveryFields(&w.Delivery), &w.URL, &w.Secret)...); err != nil {
+			return nil, err
+		}
+		due = append(due, w)
+	}
+	return due, rows.Err()
+}
+
+// AddAttempt записывает попытку доставки
+func (r *WebhookRepo) AddAttempt(ctx context.Context, a *model.WebhookAttempt) error {
+	const q = `
+        INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+        RETURNING id, created_at
+    `
+	return r.conn(ctx).QueryRow(ctx, q,
+		a.DeliveryID, a.Attempt, a.StatusCode, a.Error, a.DurationMs,
+	).Scan(&a.ID, &a.CreatedAt)
+}
+
+// FinishAttempt увеличивает счётчик попыток и переводит доставку в status;
+// для pending следующая попытка — в nextAttempt
+func (r *WebhookRepo) FinishAttempt(ctx context.Context, deliveryID int64, status string, nextAttempt time.Time) error {
+	const q = `
+        UPDATE webhook_deliveries SET
+            attempts = attempts + 1,
+            status = $2,
+            next_attempt_at = $3,
+            delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+        WHERE id = $1
+    `
+	_, err := r.conn(ctx).Exec(ctx, q, deliveryID, status, nextAttempt)
+	return err
+}
+
+// ResetFailures обнуляет счётчик неудач подряд после успешной доставки
+func (r *WebhookRepo) ResetFailures(ctx context.Context, webhookID int64) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE webhooks SET failure_count = 0 WHERE id = $1 AND failure_count > 0", webhookID)
+	return err
+}
+
+// AddFailure увеличивает счётчик неудач подряд и выключает webhook, когда он достигает disableAfter.
+// disabled — webhook выключен этим вызовом.
+func (r *WebhookRepo) AddFailure(ctx context.Context, webhookID int64, disableAfter int) (bool, error) {
+	const q = `
+        UPDATE webhooks w SET
+            failure_count = w.failure_count + 1,
+            enabled = w.enabled AND w.failure_count + 1 < $2,
+            disabled_at = CASE WHEN w.enabled AND w.failure_count + 1 >= $2 THEN NOW() ELSE w.disabled_at END,
+            updated_at = NOW()
+        FROM webhooks prev
+        WHERE w.id = $1 AND prev.id = w.id
+        RETURNING prev.enabled AND NOT w.enabled
+    `
+	var disabled bool
+	err := r.conn(ctx).QueryRow(ctx, q, webhookID, disableAfter).Scan(&disabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, ErrWebhookNotFound
+	}
+	return disabled, err
+}
+
+// ListDeliveries последние limit доставок webhook, новые первыми
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	q := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d WHERE d.webhook_id = $1 ORDER BY d.id DESC LIMIT $2`
+	rows, err := r.conn(ctx).Query(ctx, q, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		if err := rows.Scan(deliveryFields(&d)...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ListAttempts попытки доставки deliveryID webhook webhookID по порядку
+func (r *WebhookRepo) ListAttempts(ctx context.Context, webhookID, deliveryID int64) ([]model.WebhookAttempt, error) {
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2)",
+		deliveryID, webhookID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrDeliveryNotFound
+	}
+
+	const q = `
+        SELECT id, delivery_id, attempt, status_code, COALESCE(error, ''), duration_ms, created_at
+        FROM webhook_attempts
+        WHERE delivery_id = $1
+        ORDER BY attempt
+    `
+	rows, err := r.conn(ctx).Query(ctx, q, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []model.WebhookAttempt
+	for rows.Next() {
+		var a model.WebhookAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
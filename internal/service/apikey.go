@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// apiKeyPrefix префикс ключа, по которому его легко узнать в логах и конфигурации
+const apiKeyPrefix = "efm"
+
+var (
+	// ErrAPIKeyNotFound ключ не найден или принадлежит другому пользователю
+	ErrAPIKeyNotFound = repo.ErrAPIKeyNotFound
+	// ErrInvalidAPIKey ключ не существует или секрет не совпал
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	ErrAPIKeyExpired = errors.New("api key expired")
+	// ErrAPIKeyUnavailable ключ не удалось проверить (ошибка БД), детали только в логах
+	ErrAPIKeyUnavailable = errors.New("api key verification unavailable")
+)
+
+// APIKeyServiceInterface интерфейс для сервиса API ключей
+type APIKeyServiceInterface interface {
+	Create(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error)
+	List(ctx context.Context, userID string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id int64, userID string) error
+	Authenticate(ctx context.Context, raw string) (*model.APIKey, error)
+}
+
+type APIKeyService struct {
+	repo repo.APIKeyRepoInterface
+}
+
+func NewAPIKeyService(r repo.APIKeyRepoInterface) *APIKeyService {
+	return &APIKeyService{repo: r}
+}
+
+// Create выпускает новый ключ. Секрет возвращается только здесь и больше нигде не доступен.
+// Формат ключа: efm_<prefix>_<secret>.
+// scopes проверяются по правам владельца только здесь: роли приходят из JWT и на сервере не хранятся,
+// поэтому после понижения роли пользователя его ключи нужно отозвать.
+func (s *APIKeyService) Create(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	prefix, err := randomHex(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomBase64(32)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, secret)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("api key hash: %w", err)
+	}
+
+	k := &model.APIKey{
+		Prefix:    prefix,
+		Hash:      string(hash),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, k); err != nil {
+		logger.L.Error("apikey.create.failed", zap.String("user_id", userID), zap.Error(err))
+		return nil, "", err
+	}
+
+	logger.L.Info("apikey.create.ok",
+		zap.Int64("id", k.ID),
+		zap.String("user_id", userID),
+		zap.String("prefix", prefix),
+	)
+	return k, raw, nil
+}
+
+// List возвращает ключи пользователя (без секретов)
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]model.APIKey, error) {
+	keys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		logger.L.Error("apikey.list.failed", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ пользователя
+func (s *APIKeyService) Revoke(ctx context.Context, id int64, userID string) error {
+	if err := s.repo.Revoke(ctx, id, userID); err != nil {
+		logger.L.Error("apikey.revoke.failed", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	logger.L.Info("apikey.revoke.ok", zap.Int64("id", id), zap.String("user_id", userID))
+	return nil
+}
+
+// Authenticate проверяет ключ из заголовка и возвращает его владельца и права.
+// Возвращает только ошибки этого пакета: их текст безопасно отдавать клиенту.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*model.APIKey, error) {
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrInvalidAPIKey
+	}
+
+	k, err := s.repo.GetByPrefix(ctx, parts[1])
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		logger.L.Error("apikey.lookup.failed", zap.Error(err))
+		return nil, ErrAPIKeyUnavailable
+	}
+
+	// bcrypt дорогой, поэтому сверяется только ключ с существующим префиксом
+	if err := bcrypt.CompareHashAndPassword([]byte(k.Hash), []byte(raw)); err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if k.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, k.ID); err != nil {
+		logger.L.Warn("apikey.touch.failed", zap.Int64("id", k.ID), zap.Error(err))
+	}
+	return k, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomBase64(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// RawURLEncoding может содержать "_", поэтому секрет всегда последняя часть ключа
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, k *model.APIKey) error {
+	return m.Called(ctx, k).Error(0)
+}
+
+func (m *MockAPIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id int64, userID string) error {
+	return m.Called(ctx, id, userID).Error(0)
+}
+
+func (m *MockAPIKeyRepo) TouchLastUsed(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockAPIKeyRepo)
+	svc := service.NewAPIKeyService(mockRepo)
+
+	var stored *model.APIKey
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.APIKey")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*model.APIKey)
+			stored.ID = 42
+		}).Return(nil)
+
+	key, secret, err := svc.Create(ctx, "user1", "batch", []string{"subscriptions:read"}, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "efm_"+key.Prefix+"_"))
+	assert.NotContains(t, key.Hash, secret)
+	assert.True(t, strings.HasPrefix(key.Hash, "$2"), "bcrypt hash")
+
+	mockRepo.On("GetByPrefix", ctx, key.Prefix).Return(stored, nil)
+	mockRepo.On("TouchLastUsed", ctx, int64(42)).Return(nil)
+
+	got, err := svc.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", got.UserID)
+	assert.Equal(t, []string{"subscriptions:read"}, got.Scopes)
+
+	_, err = svc.Authenticate(ctx, secret+"x")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	past := time.Now().Add(-time.Hour)
+	stored.ExpiresAt = &past
+	_, err = svc.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, service.ErrAPIKeyExpired)
+
+	stored.RevokedAt = &past
+	_, err = svc.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, service.ErrAPIKeyRevoked)
+}
+
+func TestAPIKeyService_AuthenticateUnknown(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockAPIKeyRepo)
+	svc := service.NewAPIKeyService(mockRepo)
+
+	mockRepo.On("GetByPrefix", ctx, "abcdef").Return(nil, repo.ErrAPIKeyNotFound)
+
+	_, err := svc.Authenticate(ctx, "efm_abcdef_secret")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	_, err = svc.Authenticate(ctx, "not-a-key")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+)
+
+// ReplayHeader заголовок повторно опубликованного события (значение "true")
+const ReplayHeader = "replay"
+
+// Ограничения скорости replay, событий в секунду
+const (
+	defaultReplayRate = 100
+	maxReplayRate     = 1000
+)
+
+// replayBatch сколько событий журнала читается за запрос
+const replayBatch = 500
+
+var (
+	// ErrInvalidReplay некорректные параметры replay
+	ErrInvalidReplay = errors.New("invalid replay request")
+	// ErrReplayRunning replay уже выполняется
+	ErrReplayRunning = errors.New("replay already running")
+)
+
+// EventLog журнал событий (repo.EventLogRepo); см. SubscriptionService.SetEventLog
+type EventLog interface {
+	Add(ctx context.Context, e *model.EventLogEntry) error
+}
+
+// EventReplayServiceInterface интерфейс для сервиса повторной публикации событий
+type EventReplayServiceInterface interface {
+	Replay(ctx context.Context, f model.EventLogFilter, rate int, dryRun bool) (*model.ReplayResult, error)
+}
+
+// EventReplayService повторно публикует события из журнала с заголовком replay=true,
+// чтобы потребители перестроили проекции. Одновременно выполняется один replay на все реплики:
+// running отсекает повторный запуск на этой реплике, advisory lock (TryLockReplay) — на остальных.
+type EventReplayService struct {
+	ctx context.Context // отменяется при остановке сервиса; прерывает replay
+	log repo.EventLogRepoInterface
+	pub Publisher
+
+	mu      sync.Mutex
+	running bool
+	wg      sync.WaitGroup
+}
+
+func NewEventReplayService(ctx context.Context, log repo.EventLogRepoInterface, pub Publisher) *EventReplayService {
+	return &EventReplayService{ctx: ctx, log: log, pub: pub}
+}
+
+// Replay считает события под фильтром и, если это не dryRun, публикует их в фоне не быстрее rate
+// событий в секунду (0 — по умолчанию). События, записанные после запуска, не публикуются.
+func (s *EventReplayService) Replay(ctx context.Context, f model.EventLogFilter, rate int, dryRun bool) (*model.ReplayResult, error) {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidReplay)
+	}
+	if rate == 0 {
+		rate = defaultReplayRate
+	}
+	if rate < 0 || rate > maxReplayRate {
+		return nil, fmt.Errorf("%w: rate must be between 1 and %d", ErrInvalidReplay, maxReplayRate)
+	}
+
+	count, lastID, err := s.log.Stats(ctx, f)
+	if err != nil {
+		logger.L.Error("events.replay.stats_failed", zap.Error(err))
+		return nil, err
+	}
+	res := &model.ReplayResult{Matched: count, LastID: lastID, DryRun: dryRun}
+	if dryRun || count == 0 {
+		return res, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return nil, ErrReplayRunning
+	}
+	unlock, ok, err := s.log.TryLockReplay(ctx)
+	if err != nil {
+		logger.L.Error("events.replay.lock_failed", zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrReplayRunning
+	}
+	s.running = true
+	s.wg.Add(1)
+	go s.run(f, lastID, rate, unlock)
+
+	logger.L.Info("events.replay.started",
+		zap.Int64("matched", count),
+		zap.Int64("last_id", lastID),
+		zap.Int("rate", rate))
+	return res, nil
+}
+
+// Wait ждёт завершения текущего replay (после отмены ctx сервиса — его прерывания)
+func (s *EventReplayService) Wait() {
+	s.wg.Wait()
+}
+
+// run публикует события до lastID включительно; при ошибке публикации останавливается,
+// и replay можно продолжить с after_id из лога
+func (s *EventReplayService) run(f model.EventLogFilter, lastID int64, rate int, unlock func()) {
+	defer s.wg.Done()
+	defer func() {
+		unlock()
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	tick := time.NewTicker(time.Second / time.Duration(rate))
+	defer tick.Stop()
+
+	headers := map[string]string{ReplayHeader: "true"}
+	var published int64
+	for {
+		entries, err := s.log.List(s.ctx, f, replayBatch)
+		if err != nil {
+			logger.L.Error("events.replay.failed", zap.Int64("published", published), zap.Int64("after_id", f.AfterID), zap.Error(err))
+			return
+		}
+		for _, e := range entries {
+			if e.ID > lastID {
+				logger.L.Info("events.replay.ok", zap.Int64("published", published))
+				return
+			}
+			select {
+			case <-s.ctx.Done():
+				logger.L.Warn("events.replay.canceled", zap.Int64("published", published), zap.Int64("after_id", f.AfterID))
+				return
+			case <-tick.C:
+			}
+			if err := s.pub.PublishWithHeaders(e.Exchange, e.RoutingKey, e.Payload, headers); err != nil {
+				logger.L.Error("events.replay.failed", zap.Int64("published", published), zap.Int64("after_id", f.AfterID), zap.Error(err))
+				return
+			}
+			published++
+			f.AfterID = e.ID
+		}
+		if len(entries) < replayBatch {
+			logger.L.Info("events.replay.ok", zap.Int64("published", published))
+			return
+		}
+	}
+}
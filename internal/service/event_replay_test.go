@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEventLogRepo struct {
+	mock.Mock
+}
+
+func (m *MockEventLogRepo) Add(ctx context.Context, e *model.EventLogEntry) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockEventLogRepo) Stats(ctx context.Context, f model.EventLogFilter) (int64, int64, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEventLogRepo) List(ctx context.Context, f model.EventLogFilter, limit int) ([]model.EventLogEntry, error) {
+	args := m.Called(ctx, f, limit)
+	return args.Get(0).([]model.EventLogEntry), args.Error(1)
+}
+
+func (m *MockEventLogRepo) TryLockReplay(ctx context.Context) (func(), bool, error) {
+	args := m.Called(ctx)
+	unlock, _ := args.Get(0).(func())
+	return unlock, args.Bool(1), args.Error(2)
+}
+
+// lockReplay ожидает захват блокировки replay и возвращает счётчик её снятий
+func lockReplay(repo *MockEventLogRepo) *atomic.Int32 {
+	unlocked := new(atomic.Int32)
+	repo.On("TryLockReplay", mock.Anything).Return(func() { unlocked.Add(1) }, true, nil)
+	return unlocked
+}
+
+func afterID(id int64) interface{} {
+	return mock.MatchedBy(func(f model.EventLogFilter) bool { return f.AfterID == id })
+}
+
+func logEntry(id int64, key string) model.EventLogEntry {
+	return model.EventLogEntry{ID: id, Exchange: "subscriptions", RoutingKey: key, Payload: []byte(`{"id":"e"}`)}
+}
+
+func TestEventReplayService_DryRun(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockEventLogRepo)
+	bus := service.NewMemoryBus()
+	svc := service.NewEventReplayService(ctx, repo, bus)
+
+	f := model.EventLogFilter{UserID: "u1"}
+	repo.On("Stats", ctx, f).Return(int64(12), int64(40), nil)
+
+	res, err := svc.Replay(ctx, f, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, &model.ReplayResult{Matched: 12, LastID: 40, DryRun: true}, res)
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEventReplayService_Replay(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockEventLogRepo)
+	bus := service.NewMemoryBus()
+	msgs, _ := bus.Subscribe("", 10)
+	svc := service.NewEventReplayService(ctx, repo, bus)
+
+	unlocked := lockReplay(repo)
+	// событие 3 записано после запуска и не публикуется
+	repo.On("Stats", ctx, mock.Anything).Return(int64(2), int64(2), nil)
+	repo.On("List", mock.Anything, afterID(0), mock.Anything).
+		Return([]model.EventLogEntry{logEntry(1, "created"), logEntry(2, "deleted"), logEntry(3, "updated")}, nil)
+
+	res, err := svc.Replay(ctx, model.EventLogFilter{}, 1000, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Matched)
+	svc.Wait()
+	assert.Equal(t, int32(1), unlocked.Load())
+
+	require.Len(t, msgs, 2)
+	for _, key := range []string{"created", "deleted"} {
+		m := <-msgs
+		assert.Equal(t, key, m.RoutingKey)
+		assert.Equal(t, map[string]string{service.ReplayHeader: "true"}, m.Headers)
+	}
+}
+
+func TestEventReplayService_Invalid(t *testing.T) {
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, -1, 0)
+
+	tests := []struct {
+		name string
+		f    model.EventLogFilter
+		rate int
+	}{
+		{"from after to", model.EventLogFilter{From: &from, To: &to}, 0},
+		{"negative rate", model.EventLogFilter{}, -1},
+		{"rate too high", model.EventLogFilter{}, 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockEventLogRepo)
+			svc := service.NewEventReplayService(context.Background(), repo, service.NewMemoryBus())
+
+			_, err := svc.Replay(context.Background(), tt.f, tt.rate, false)
+			assert.ErrorIs(t, err, service.ErrInvalidReplay)
+			repo.AssertNotCalled(t, "Stats", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEventReplayService_Running(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := new(MockEventLogRepo)
+	svc := service.NewEventReplayService(ctx, repo, service.NewMemoryBus())
+
+	release := make(chan time.Time)
+	lockReplay(repo)
+	repo.On("Stats", mock.Anything, mock.Anything).Return(int64(1), int64(1), nil)
+	repo.On("List", mock.Anything, mock.Anything, mock.Anything).WaitUntil(release).
+		Return([]model.EventLogEntry{logEntry(1, "created")}, nil)
+
+	_, err := svc.Replay(ctx, model.EventLogFilter{}, 0, false)
+	require.NoError(t, err)
+	_, err = svc.Replay(ctx, model.EventLogFilter{}, 0, false)
+	assert.ErrorIs(t, err, service.ErrReplayRunning)
+
+	close(release)
+	svc.Wait()
+	_, err = svc.Replay(ctx, model.EventLogFilter{}, 0, true)
+	assert.NoError(t, err)
+}
+
+func TestEventReplayService_RunningOnOtherReplica(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockEventLogRepo)
+	svc := service.NewEventReplayService(ctx, repo, service.NewMemoryBus())
+
+	repo.On("Stats", ctx, mock.Anything).Return(int64(1), int64(1), nil)
+	repo.On("TryLockReplay", ctx).Return(nil, false, nil)
+
+	_, err := svc.Replay(ctx, model.EventLogFilter{}, 0, false)
+	assert.ErrorIs(t, err, service.ErrReplayRunning)
+	svc.Wait()
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
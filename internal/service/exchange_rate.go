@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+)
+
+// ErrInvalidExchangeRate некорректный курс во входных данных (ошибка клиента)
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+// ExchangeRateServiceInterface интерфейс для сервиса курсов валют
+type ExchangeRateServiceInterface interface {
+	Upsert(ctx context.Context, rates []model.ExchangeRate) error
+	List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error)
+}
+
+type ExchangeRateService struct {
+	repo  repo.ExchangeRateRepoInterface
+	redis RedisInterface
+}
+
+// NewExchangeRateService создаёт сервис курсов. redis может быть nil — тогда кеш сумм не сбрасывается.
+func NewExchangeRateService(r repo.ExchangeRateRepoInterface, redisClient RedisInterface) *ExchangeRateService {
+	return &ExchangeRateService{repo: r, redis: redisClient}
+}
+
+// Upsert проверяет и сохраняет курсы. Суммы в кеше считались по старым курсам, поэтому сбрасываются все.
+func (s *ExchangeRateService) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return fmt.Errorf("%w: no rates", ErrInvalidExchangeRate)
+	}
+	for i := range rates {
+		if err := normalizeRate(&rates[i]); err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidExchangeRate, i, err)
+		}
+	}
+
+	if err := s.repo.Upsert(ctx, rates); err != nil {
+		logger.L.Error("rates.upsert.failed", zap.Int("count", len(rates)), zap.Error(err))
+		return err
+	}
+	if s.redis != nil {
+		deleteKeys(ctx, s.redis, "summary:*")
+	}
+
+	logger.L.Info("rates.upsert.ok", zap.Int("count", len(rates)))
+	return nil
+}
+
+// List возвращает курсы, новые первыми внутри каждой пары
+func (s *ExchangeRateService) List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	rates, err := s.repo.List(ctx, f)
+	if err != nil {
+		logger.L.Error("rates.list.failed", zap.Error(err))
+		return nil, err
+	}
+	return rates, nil
+}
+
+func normalizeRate(r *model.ExchangeRate) error {
+	var err error
+	if r.Base, err = model.NormalizeCurrency(r.Base); err != nil {
+		return err
+	}
+	if r.Quote, err = model.NormalizeCurrency(r.Quote); err != nil {
+		return err
+	}
+	if r.Base == r.Quote {
+		return fmt.Errorf("base and quote are both %s", r.Base)
+	}
+	if r.Date.IsZero() {
+		return errors.New("date is required")
+	}
+	if !(r.Rate > 0) {
+		return fmt.Errorf("rate must be positive, got %v", r.Rate)
+	}
+	return nil
+}
+
+// ParseRatesCSV читает курсы из CSV с колонками date,base,quote,rate (дата в формате YYYY-MM-DD).
+// Строка заголовка, если есть, пропускается.
+func ParseRatesCSV(r io.Reader) ([]model.ExchangeRate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+
+	var rates []model.ExchangeRate
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+		}
+		if line == 1 && strings.EqualFold(rec[0], "date") {
+			continue
+		}
+
+		date, err := time.Parse(model.RateDateLayout, rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid date %q, expect YYYY-MM-DD", ErrInvalidExchangeRate, line, rec[0])
+		}
+		rate, err := strconv.ParseFloat(rec[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid rate %q", ErrInvalidExchangeRate, line, rec[3])
+		}
+		rates = append(rates, model.ExchangeRate{Date: date, Base: rec[1], Quote: rec[2], Rate: rate})
+	}
+	return rates, nil
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockExchangeRateRepo struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateRepo) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	return m.Called(ctx, rates).Error(0)
+}
+
+func (m *MockExchangeRateRepo) List(ctx context.Context, f model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func TestExchangeRateService_Upsert(t *testing.T) {
+	date := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rate    model.ExchangeRate
+		want    model.ExchangeRate
+		wantErr bool
+	}{
+		{
+			name: "normalizes codes",
+			rate: model.ExchangeRate{Date: date, Base: "usd", Quote: " rub", Rate: 81.25},
+			want: model.ExchangeRate{Date: date, Base: "USD", Quote: "RUB", Rate: 81.25},
+		},
+		{name: "same currency", rate: model.ExchangeRate{Date: date, Base: "RUB", Quote: "RUB", Rate: 1}, wantErr: true},
+		{name: "bad code", rate: model.ExchangeRate{Date: date, Base: "US", Quote: "RUB", Rate: 1}, wantErr: true},
+		{name: "zero rate", rate: model.ExchangeRate{Date: date, Base: "USD", Quote: "RUB"}, wantErr: true},
+		{name: "missing date", rate: model.ExchangeRate{Base: "USD", Quote: "RUB", Rate: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(MockExchangeRateRepo)
+			svc := service.NewExchangeRateService(mockRepo, nil)
+
+			if !tt.wantErr {
+				mockRepo.On("Upsert", ctx, []model.ExchangeRate{tt.want}).Return(nil)
+			}
+
+			err := svc.Upsert(ctx, []model.ExchangeRate{tt.rate})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, service.ErrInvalidExchangeRate)
+				mockRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseRatesCSV(t *testing.T) {
+	in := "date,base,quote,rate\n2025-07-01,USD,RUB,81.25\n2025-07-01, EUR, RUB, 92.5\n"
+
+	rates, err := service.ParseRatesCSV(strings.NewReader(in))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), rates[0].Date)
+	assert.Equal(t, "EUR", rates[1].Base)
+	assert.Equal(t, 92.5, rates[1].Rate)
+
+	_, err = service.ParseRatesCSV(strings.NewReader("07-2025,USD,RUB,81\n"))
+	assert.ErrorIs(t, err, service.ErrInvalidExchangeRate)
+
+	_, err = service.ParseRatesCSV(strings.NewReader("2025-07-01,USD,RUB\n"))
+	assert.ErrorIs(t, err, service.ErrInvalidExchangeRate)
+}
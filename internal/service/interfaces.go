@@ -4,19 +4,27 @@ import (
 	"context"
 	"time"
 
+	"github.com/iokiris/efm-subscription-api/internal/events"
 	"github.com/iokiris/efm-subscription-api/internal/model"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// SubscriptionServiceInterface интерфейс для сервиса подписок
+// SubscriptionServiceInterface интерфейс для сервиса подписок.
+// Методы с параметром userID проверяют владельца, если userID не пустой.
 type SubscriptionServiceInterface interface {
 	Create(ctx context.Context, sub *model.Subscription) error
-	Update(ctx context.Context, sub *model.Subscription) error
+	Update(ctx context.Context, sub *model.Subscription, userID string) error
 	Delete(ctx context.Context, id int64, userID string) error
-	Get(ctx context.Context, id int64) (*model.Subscription, error)
-	List(ctx context.Context, userID string) ([]model.Subscription, error)
-	GetSummary(ctx context.Context, userID, serviceName, from, to string) (int, error)
+	Get(ctx context.Context, id int64, userID string) (*model.Subscription, error)
+	Prices(ctx context.Context, id int64, userID string) ([]model.SubscriptionPrice, error)
+	Pause(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error)
+	Resume(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error)
+	List(ctx context.Context, q model.SubscriptionListQuery) (*model.SubscriptionPage, error)
+	ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error)
+	Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error)
+	SuggestServices(ctx context.Context, query string, limit int) ([]string, error)
+	GetSummary(ctx context.Context, q model.SummaryQuery) (*model.Summary, error)
 }
 
 // RedisInterface интерфейс для Redis клиента
@@ -26,3 +34,8 @@ type RedisInterface interface {
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
 	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
 }
+
+// Notifier получает события пользователя после коммита (stream.Hub)
+type Notifier interface {
+	Notify(userID string, env *events.Envelope)
+}
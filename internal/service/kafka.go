@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// KafkaWriter записывает сообщения и ждёт подтверждения брокеров (*kafka.Writer)
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaPublisher публикует события в Kafka: топик — exchange, ключ — subject конверта (id подписки),
+// поэтому события одной подписки попадают в одну партицию и читаются по порядку.
+// routingKey передаётся в заголовке routing_key.
+type KafkaPublisher struct {
+	w       KafkaWriter
+	timeout time.Duration
+	gate    *gate
+}
+
+func NewKafkaPublisher(w KafkaWriter, timeout time.Duration) *KafkaPublisher {
+	return &KafkaPublisher{w: w, timeout: timeout, gate: newGate()}
+}
+
+// Publish записывает сообщение и ждёт подтверждения не дольше timeout
+func (p *KafkaPublisher) Publish(exchange, routingKey string, body []byte) error {
+	return p.PublishWithHeaders(exchange, routingKey, body, nil)
+}
+
+// PublishWithHeaders как Publish, заголовки добавляются к content-type и routing_key
+func (p *KafkaPublisher) PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error {
+	if !p.gate.enter() {
+		return ErrPublisherClosed
+	}
+	defer p.gate.leave()
+
+	key := routingKey
+	if _, subject := events.Peek(body); subject != "" {
+		key = subject
+	}
+
+	kh := []kafka.Header{
+		{Key: "content-type", Value: []byte(events.ContentType)},
+		{Key: "routing_key", Value: []byte(routingKey)},
+	}
+	for k, v := range headers {
+		kh = append(kh, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	ctx, cancel := context.WithTimeout(p.gate.ctx, p.timeout)
+	defer cancel()
+	err := p.w.WriteMessages(ctx, kafka.Message{
+		Topic:   exchange,
+		Key:     []byte(key),
+		Value:   body,
+		Headers: kh,
+	})
+	if err != nil {
+		logger.L.Error("publish.failed",
+			zap.String("backend", "kafka"),
+			zap.String("topic", exchange),
+			zap.String("routing_key", routingKey),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Close перестаёт принимать сообщения и ждёт текущие публикации (см. Publisher)
+func (p *KafkaPublisher) Close(ctx context.Context) int {
+	return p.gate.close(ctx)
+}
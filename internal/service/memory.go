@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// Message сообщение MemoryBus
+type Message struct {
+	Exchange   string
+	RoutingKey string
+	Body       []byte
+	Headers    map[string]string
+}
+
+// MemoryBus шина событий внутри процесса: для тестов и запуска одним бинарником без брокера.
+// Publish раздаёт сообщение подписчикам без ожидания; если буфер подписчика полон,
+// возвращается ErrPublisherQueueFull, и outbox повторит событие (остальные подписчики получат его ещё раз).
+type MemoryBus struct {
+	gate *gate
+
+	mu   sync.RWMutex
+	subs map[*memorySub]struct{}
+}
+
+type memorySub struct {
+	routingKey string
+	ch         chan Message
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{gate: newGate(), subs: make(map[*memorySub]struct{})}
+}
+
+// Subscribe подписывает на сообщения с ключом routingKey ("" — все) с буфером buffer.
+// cancel отписывает и закрывает канал; Close шины закрывает каналы всех подписчиков.
+func (b *MemoryBus) Subscribe(routingKey string, buffer int) (<-chan Message, func()) {
+	sub := &memorySub{routingKey: routingKey, ch: make(chan Message, buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Publish раздаёт сообщение подходящим подписчикам
+func (b *MemoryBus) Publish(exchange, routingKey string, body []byte) error {
+	return b.PublishWithHeaders(exchange, routingKey, body, nil)
+}
+
+// PublishWithHeaders как Publish, заголовки передаются в Message.Headers
+func (b *MemoryBus) PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error {
+	if !b.gate.enter() {
+		return ErrPublisherClosed
+	}
+	defer b.gate.leave()
+
+	msg := Message{Exchange: exchange, RoutingKey: routingKey, Body: body, Headers: headers}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var err error
+	for sub := range b.subs {
+		if sub.routingKey != "" && sub.routingKey != routingKey {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			err = ErrPublisherQueueFull
+		}
+	}
+	return err
+}
+
+// Close перестаёт принимать сообщения и закрывает каналы подписчиков
+func (b *MemoryBus) Close(ctx context.Context) int {
+	dropped := b.gate.close(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		close(sub.ch)
+	}
+	b.subs = nil
+	return dropped
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// JetStreamPublisher публикует сообщение в JetStream и ждёт подтверждения (jetstream.JetStream)
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// NATSPublisher публикует события в NATS JetStream в subject <exchange>.<routingKey>.
+// id конверта CloudEvents передаётся как Nats-Msg-Id: повтор из outbox в окне дедупликации stream не задваивается.
+type NATSPublisher struct {
+	js      JetStreamPublisher
+	timeout time.Duration
+	gate    *gate
+}
+
+func NewNATSPublisher(js JetStreamPublisher, timeout time.Duration) *NATSPublisher {
+	return &NATSPublisher{js: js, timeout: timeout, gate: newGate()}
+}
+
+// Publish публикует сообщение и ждёт PubAck не дольше timeout
+func (p *NATSPublisher) Publish(exchange, routingKey string, body []byte) error {
+	return p.PublishWithHeaders(exchange, routingKey, body, nil)
+}
+
+// PublishWithHeaders как Publish, заголовки передаются в NATS headers
+func (p *NATSPublisher) PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error {
+	if !p.gate.enter() {
+		return ErrPublisherClosed
+	}
+	defer p.gate.leave()
+
+	msg := nats.NewMsg(exchange + "." + routingKey)
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+	msg.Header.Set("Content-Type", events.ContentType)
+	msg.Data = body
+
+	var opts []jetstream.PublishOpt
+	if id, _ := events.Peek(body); id != "" {
+		opts = append(opts, jetstream.WithMsgID(id))
+	}
+
+	ctx, cancel := context.WithTimeout(p.gate.ctx, p.timeout)
+	defer cancel()
+	if _, err := p.js.PublishMsg(ctx, msg, opts...); err != nil {
+		logger.L.Error("publish.failed",
+			zap.String("backend", "nats"),
+			zap.String("subject", msg.Subject),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Close перестаёт принимать сообщения и ждёт текущие публикации (см. Publisher)
+func (p *NATSPublisher) Close(ctx context.Context) int {
+	return p.gate.close(ctx)
+}
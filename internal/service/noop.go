@@ -0,0 +1,28 @@
+package service
+
+import "context"
+
+// NoopPublisher отбрасывает события: для окружений без брокера, где события никому не нужны
+type NoopPublisher struct {
+	gate *gate
+}
+
+func NewNoopPublisher() *NoopPublisher {
+	return &NoopPublisher{gate: newGate()}
+}
+
+func (p *NoopPublisher) Publish(exchange, routingKey string, body []byte) error {
+	return p.PublishWithHeaders(exchange, routingKey, body, nil)
+}
+
+func (p *NoopPublisher) PublishWithHeaders(_, _ string, _ []byte, _ map[string]string) error {
+	if !p.gate.enter() {
+		return ErrPublisherClosed
+	}
+	p.gate.leave()
+	return nil
+}
+
+func (p *NoopPublisher) Close(ctx context.Context) int {
+	return p.gate.close(ctx)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrPublisherQueueFull очередь публикации переполнена
+	ErrPublisherQueueFull = errors.New("publisher queue full")
+	// ErrPublisherClosed публикатор закрыт
+	ErrPublisherClosed = errors.New("publisher closed")
+)
+
+// Publisher публикует события в брокер. Реализации: RabbitPublisher, NATSPublisher,
+// KafkaPublisher, MemoryBus и NoopPublisher; выбираются конфигурацией (EVENTS_BACKEND).
+// Publish возвращает nil только после того, как брокер принял сообщение.
+type Publisher interface {
+	Publish(exchange, routingKey string, body []byte) error
+	// PublishWithHeaders как Publish, но с заголовками сообщения (AMQP headers, NATS и Kafka headers)
+	PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error
+	// Close перестаёт принимать сообщения и дожидается отправки уже принятых, пока не истечёт ctx.
+	// Возвращает число сообщений, которые так и не были подтверждены.
+	Close(ctx context.Context) int
+}
+
+// gate учитывает публикации в процессе для синхронных публикаторов:
+// после close новые отклоняются, а текущие отменяются, если не успели до дедлайна Close
+type gate struct {
+	ctx    context.Context // родитель контекстов публикаций
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	closed   bool
+	wg       sync.WaitGroup
+	inflight atomic.Int64
+}
+
+func newGate() *gate {
+	g := &gate{}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	return g
+}
+
+// enter регистрирует публикацию; false — публикатор закрыт
+func (g *gate) enter() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.closed {
+		return false
+	}
+	g.wg.Add(1)
+	g.inflight.Add(1)
+	return true
+}
+
+func (g *gate) leave() {
+	g.inflight.Add(-1)
+	g.wg.Done()
+}
+
+// close ждёт текущие публикации до дедлайна ctx и возвращает число незавершённых
+func (g *gate) close(ctx context.Context) int {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.cancel()
+		return 0
+	case <-ctx.Done():
+		dropped := int(g.inflight.Load())
+		g.cancel()
+		return dropped
+	}
+}
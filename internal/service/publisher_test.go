@@ -0,0 +1,299 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn брокер в памяти: запоминает принятые сообщения, отказывает после fail, зависает после block
+type standIn struct {
+	mu      sync.Mutex
+	got     []service.Message
+	err     error
+	blocked bool
+	collect func() // забирает сообщения, доставленные асинхронно (MemoryBus)
+}
+
+func (s *standIn) accept(ctx context.Context, m service.Message) error {
+	s.mu.Lock()
+	err, blocked := s.err, s.blocked
+	if err == nil && !blocked {
+		s.got = append(s.got, m)
+	}
+	s.mu.Unlock()
+
+	if blocked {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+func (s *standIn) messages() []service.Message {
+	if s.collect != nil {
+		s.collect()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]service.Message(nil), s.got...)
+}
+
+func (s *standIn) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *standIn) block() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocked = true
+}
+
+type rabbitStandIn struct{ *standIn }
+
+func (r rabbitStandIn) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	var headers map[string]string
+	for k, v := range msg.Headers {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[k] = v.(string)
+	}
+	return r.accept(ctx, service.Message{Exchange: exchange, RoutingKey: routingKey, Body: msg.Body, Headers: headers})
+}
+
+type jetStreamStandIn struct{ *standIn }
+
+func (j jetStreamStandIn) PublishMsg(ctx context.Context, msg *nats.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	exchange, routingKey, _ := strings.Cut(msg.Subject, ".")
+	var headers map[string]string
+	for k := range msg.Header {
+		if k == "Content-Type" {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[k] = msg.Header.Get(k)
+	}
+	if err := j.accept(ctx, service.Message{Exchange: exchange, RoutingKey: routingKey, Body: msg.Data, Headers: headers}); err != nil {
+		return nil, err
+	}
+	return &jetstream.PubAck{Stream: "SUBSCRIPTIONS"}, nil
+}
+
+type kafkaStandIn struct{ *standIn }
+
+func (k kafkaStandIn) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		var routingKey string
+		var headers map[string]string
+		for _, h := range m.Headers {
+			switch h.Key {
+			case "routing_key":
+				routingKey = string(h.Value)
+			case "content-type":
+			default:
+				if headers == nil {
+					headers = make(map[string]string)
+				}
+				headers[h.Key] = string(h.Value)
+			}
+		}
+		if err := k.accept(ctx, service.Message{Exchange: m.Topic, RoutingKey: routingKey, Body: m.Value, Headers: headers}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publisherBackend реализация Publisher для общего набора проверок
+type publisherBackend struct {
+	name     string
+	new      func() (service.Publisher, *standIn)
+	delivers bool // сообщения доходят до standIn
+	brokered bool // есть внешний брокер: может отказать или не ответить
+}
+
+func publisherBackends() []publisherBackend {
+	const timeout = 200 * time.Millisecond
+	return []publisherBackend{
+		{
+			name: "rabbitmq",
+			new: func() (service.Publisher, *standIn) {
+				s := &standIn{}
+				return service.NewRabbitPublisher(rabbitStandIn{s}, 100, timeout), s
+			},
+			delivers: true,
+			brokered: true,
+		},
+		{
+			name: "nats",
+			new: func() (service.Publisher, *standIn) {
+				s := &standIn{}
+				return service.NewNATSPublisher(jetStreamStandIn{s}, timeout), s
+			},
+			delivers: true,
+			brokered: true,
+		},
+		{
+			name: "kafka",
+			new: func() (service.Publisher, *standIn) {
+				s := &standIn{}
+				return service.NewKafkaPublisher(kafkaStandIn{s}, timeout), s
+			},
+			delivers: true,
+			brokered: true,
+		},
+		{
+			name: "memory",
+			new: func() (service.Publisher, *standIn) {
+				bus := service.NewMemoryBus()
+				ch, _ := bus.Subscribe("", 100)
+				s := &standIn{}
+				s.collect = func() {
+					for {
+						select {
+						case m, ok := <-ch:
+							if !ok {
+								return
+							}
+							_ = s.accept(context.Background(), m)
+						default:
+							return
+						}
+					}
+				}
+				return bus, s
+			},
+			delivers: true,
+		},
+		{
+			name: "noop",
+			new: func() (service.Publisher, *standIn) {
+				return service.NewNoopPublisher(), &standIn{}
+			},
+		},
+	}
+}
+
+// TestPublisherConformance общие требования к реализациям Publisher
+func TestPublisherConformance(t *testing.T) {
+	body := []byte(`{"specversion":"1.0","id":"e1","subject":"42","type":"subscription.created.v1"}`)
+
+	for _, b := range publisherBackends() {
+		t.Run(b.name, func(t *testing.T) {
+			t.Run("delivers", func(t *testing.T) {
+				pub, s := b.new()
+				defer pub.Close(context.Background())
+
+				require.NoError(t, pub.Publish("subscriptions", "created", body))
+				if b.delivers {
+					assert.Equal(t, []service.Message{{Exchange: "subscriptions", RoutingKey: "created", Body: body}}, s.messages())
+				}
+			})
+
+			t.Run("headers", func(t *testing.T) {
+				pub, s := b.new()
+				defer pub.Close(context.Background())
+
+				headers := map[string]string{"replay": "true"}
+				require.NoError(t, pub.PublishWithHeaders("subscriptions", "created", body, headers))
+				if b.delivers {
+					assert.Equal(t, []service.Message{{Exchange: "subscriptions", RoutingKey: "created", Body: body, Headers: headers}}, s.messages())
+				}
+			})
+
+			t.Run("concurrent publishes", func(t *testing.T) {
+				pub, s := b.new()
+				defer pub.Close(context.Background())
+
+				var wg sync.WaitGroup
+				for i := range 20 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						assert.NoError(t, pub.Publish("subscriptions", fmt.Sprintf("k%d", i), body))
+					}()
+				}
+				wg.Wait()
+				if b.delivers {
+					assert.Len(t, s.messages(), 20)
+				}
+			})
+
+			t.Run("publish after close", func(t *testing.T) {
+				pub, s := b.new()
+
+				assert.Zero(t, pub.Close(context.Background()))
+				assert.ErrorIs(t, pub.Publish("subscriptions", "created", body), service.ErrPublisherClosed)
+				assert.Empty(t, s.messages())
+			})
+
+			if !b.brokered {
+				return
+			}
+
+			t.Run("broker failure", func(t *testing.T) {
+				pub, s := b.new()
+				defer pub.Close(context.Background())
+
+				brokerDown := errors.New("broker down")
+				s.fail(brokerDown)
+				assert.ErrorIs(t, pub.Publish("subscriptions", "created", body), brokerDown)
+			})
+
+			t.Run("close deadline drops in-flight", func(t *testing.T) {
+				pub, s := b.new()
+				s.block()
+
+				errs := make(chan error, 1)
+				go func() { errs <- pub.Publish("subscriptions", "created", body) }()
+				time.Sleep(20 * time.Millisecond)
+
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				assert.Equal(t, 1, pub.Close(ctx))
+				assert.Error(t, <-errs)
+			})
+		})
+	}
+}
+
+func TestMemoryBus_Subscribe(t *testing.T) {
+	bus := service.NewMemoryBus()
+	created, cancelCreated := bus.Subscribe("created", 1)
+	all, _ := bus.Subscribe("", 1)
+
+	require.NoError(t, bus.Publish("subscriptions", "created", []byte(`1`)))
+	assert.Equal(t, []byte(`1`), (<-created).Body)
+	assert.Equal(t, []byte(`1`), (<-all).Body)
+
+	// подписчик all не прочитал сообщение: буфер полон
+	require.NoError(t, bus.Publish("subscriptions", "deleted", []byte(`2`)))
+	assert.ErrorIs(t, bus.Publish("subscriptions", "deleted", []byte(`3`)), service.ErrPublisherQueueFull)
+
+	cancelCreated()
+	_, ok := <-created
+	assert.False(t, ok)
+
+	assert.Zero(t, bus.Close(context.Background()))
+	assert.Equal(t, []byte(`2`), (<-all).Body)
+	_, ok = <-all
+	assert.False(t, ok)
+}
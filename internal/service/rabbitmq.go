@@ -1,68 +1,301 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/infra"
 	"github.com/iokiris/efm-subscription-api/internal/logger"
 
 	"github.com/streadway/amqp"
 	"go.uber.org/zap"
 )
 
-// Publisher интерфейс для сервисного слоя
-type Publisher interface {
-	Publish(exchange, routingKey string, body []byte) error
-	Close()
+// Задержка перед повторной публикацией: удваивается с каждой попыткой
+const (
+	publishRetryMin = 100 * time.Millisecond
+	publishRetryMax = 2 * time.Second
+
+	// publishFlushPoll период проверки повторов при досылке в Close
+	publishFlushPoll = 10 * time.Millisecond
+)
+
+// ConfirmPublisher публикует сообщение и ждёт подтверждения брокера (infra.Rabbit)
+type ConfirmPublisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
 }
 
+// RabbitPublisher публикует сообщения через очередь и воркер с подтверждениями брокера.
+// Сообщение без подтверждения (nack, разрыв соединения) возвращается в очередь и повторяется,
+// пока не истечёт timeout.
 type RabbitPublisher struct {
-	ch    *amqp.Channel
-	queue chan publishMsg
+	conn    ConfirmPublisher
+	queue   chan *publishMsg
+	timeout time.Duration
+	metrics *infra.Metrics
+
+	ctx       context.Context // отменяется, когда истекает дедлайн Close
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	closing   chan struct{} // закрыт: новые Publish отклоняются
+	done      chan struct{} // закрыт: воркер завершил досылку
+
+	mu       sync.RWMutex
+	stopped  bool         // после досылки очередь не принимает даже повторы
+	retrying atomic.Int64 // сообщения, ожидающие повтора в time.AfterFunc
+	dropped  atomic.Int64 // сообщения, завершённые ошибкой после Close
 }
 
 type publishMsg struct {
 	exchange, routingKey string
 	body                 []byte
+	headers              amqp.Table
+	attempts             int
+	deadline             time.Time
+	result               chan error
 }
 
 // NewRabbitPublisher создает экземпляр Publisher с буфером и воркером
-func NewRabbitPublisher(ch *amqp.Channel, buffer int) *RabbitPublisher {
+func NewRabbitPublisher(conn ConfirmPublisher, buffer int, timeout time.Duration) *RabbitPublisher {
 	r := &RabbitPublisher{
-		ch:    ch,
-		queue: make(chan publishMsg, buffer),
+		conn:    conn,
+		queue:   make(chan *publishMsg, buffer),
+		timeout: timeout,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
 	go r.worker()
 	return r
 }
 
+// SetMetrics устанавливает метрики для публикатора
+func (r *RabbitPublisher) SetMetrics(metrics *infra.Metrics) {
+	r.metrics = metrics
+}
+
 func (r *RabbitPublisher) worker() {
-	for msg := range r.queue {
-		err := r.ch.Publish(
-			msg.exchange,
-			msg.routingKey,
-			false, false,
-			amqp.Publishing{
-				ContentType: "application/json",
-				Body:        msg.body,
-			},
-		)
-		if err != nil {
-			logger.L.Error("RabbitPublisher publish error:", zap.Error(err))
+	defer close(r.done)
+	for {
+		select {
+		case <-r.closing:
+			r.flush()
+			return
+		case msg := <-r.queue:
+			r.publish(msg)
 		}
 	}
 }
 
-// Publish помещает сообщение в очередь
-func (r *RabbitPublisher) Publish(exchange, routingKey string, body []byte) error {
+// flush досылает сообщения из очереди, включая ожидающие повтора, пока очередь не опустеет
+// или не истечёт дедлайн Close
+func (r *RabbitPublisher) flush() {
+	tick := time.NewTicker(publishFlushPoll)
+	defer tick.Stop()
+
+	for {
+		select {
+		case msg := <-r.queue:
+			r.publish(msg)
+			continue
+		default:
+		}
+		if r.retrying.Load() == 0 {
+			return
+		}
+
+		select {
+		case msg := <-r.queue:
+			r.publish(msg)
+		case <-tick.C:
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *RabbitPublisher) publish(msg *publishMsg) {
+	if r.ctx.Err() != nil {
+		r.fail(msg, ErrPublisherClosed)
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(r.ctx, msg.deadline)
+	err := r.conn.Publish(ctx, msg.exchange, msg.routingKey, amqp.Publishing{
+		Headers:      msg.headers,
+		ContentType:  events.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Body:         msg.body,
+	})
+	cancel()
+
+	if err == nil {
+		if r.metrics != nil {
+			r.metrics.RabbitMQMessagesPublished.WithLabelValues(msg.exchange, msg.routingKey).Inc()
+		}
+		msg.result <- nil
+		return
+	}
+
+	msg.attempts++
+	delay := min(publishRetryMin<<(msg.attempts-1), publishRetryMax)
+	if r.ctx.Err() != nil {
+		// дедлайн Close истёк во время публикации
+		r.fail(msg, ErrPublisherClosed)
+		return
+	}
+	if time.Now().Add(delay).After(msg.deadline) {
+		r.fail(msg, err)
+		return
+	}
+
+	logger.L.Warn("publish.retry",
+		zap.String("exchange", msg.exchange),
+		zap.String("routing_key", msg.routingKey),
+		zap.Int("attempt", msg.attempts),
+		zap.Error(err))
+	r.retrying.Add(1)
+	time.AfterFunc(delay, func() { r.requeue(msg) })
+}
+
+// push кладёт сообщение в очередь без ожидания; вызывается под r.mu
+func (r *RabbitPublisher) push(msg *publishMsg) error {
+	if r.stopped {
+		return ErrPublisherClosed
+	}
 	select {
-	case r.queue <- publishMsg{exchange, routingKey, body}:
+	case r.queue <- msg:
 		return nil
 	default:
-		return fmt.Errorf("publisher queue full")
+		return ErrPublisherQueueFull
+	}
+}
+
+// requeue возвращает сообщение в очередь для повторной публикации
+func (r *RabbitPublisher) requeue(msg *publishMsg) {
+	// retrying уменьшается под той же блокировкой, чтобы Close не посчитал сообщение дважды
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.push(msg); err != nil {
+		r.fail(msg, err)
+	}
+	r.retrying.Add(-1)
+}
+
+func (r *RabbitPublisher) fail(msg *publishMsg, err error) {
+	select {
+	case <-r.closing:
+		r.dropped.Add(1)
+	default:
+	}
+	if r.metrics != nil {
+		r.metrics.RabbitMQMessagesFailed.WithLabelValues(msg.exchange, msg.routingKey, publishErrorType(err)).Inc()
+	}
+	logger.L.Error("publish.failed",
+		zap.String("exchange", msg.exchange),
+		zap.String("routing_key", msg.routingKey),
+		zap.Int("attempts", msg.attempts),
+		zap.Error(err))
+	msg.result <- err
+}
+
+// drain завершает ошибкой сообщения, оставшиеся в очереди после досылки
+func (r *RabbitPublisher) drain() {
+	for {
+		select {
+		case msg := <-r.queue:
+			r.fail(msg, ErrPublisherClosed)
+		default:
+			return
+		}
+	}
+}
+
+// Publish ставит сообщение в очередь и ждёт подтверждения брокера
+func (r *RabbitPublisher) Publish(exchange, routingKey string, body []byte) error {
+	return r.PublishWithHeaders(exchange, routingKey, body, nil)
+}
+
+// PublishWithHeaders как Publish, заголовки передаются в AMQP headers
+func (r *RabbitPublisher) PublishWithHeaders(exchange, routingKey string, body []byte, headers map[string]string) error {
+	msg := &publishMsg{
+		exchange:   exchange,
+		routingKey: routingKey,
+		body:       body,
+		deadline:   time.Now().Add(r.timeout),
+		result:     make(chan error, 1),
+	}
+	if len(headers) > 0 {
+		msg.headers = make(amqp.Table, len(headers))
+		for k, v := range headers {
+			msg.headers[k] = v
+		}
+	}
+
+	select {
+	case <-r.closing:
+		return ErrPublisherClosed
+	default:
+	}
+
+	r.mu.RLock()
+	err := r.push(msg)
+	r.mu.RUnlock()
+	if err != nil {
+		r.fail(msg, err)
+		return err
+	}
+
+	// принятое сообщение всегда получает результат: от воркера, досылки или drain в Close
+	return <-msg.result
+}
+
+// Close перестаёт принимать сообщения и досылает очередь, пока не истечёт ctx.
+// Неотправленные сообщения завершаются ErrPublisherClosed; возвращается их число.
+// Соединение с брокером закрывается отдельно, после Close.
+func (r *RabbitPublisher) Close(ctx context.Context) int {
+	r.closeOnce.Do(func() { close(r.closing) })
+	stop := context.AfterFunc(ctx, r.cancel)
+	<-r.done
+	stop()
+	r.cancel()
+
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.drain()
+
+	r.mu.Lock()
+	// повторы, ещё ждущие таймера, завершатся ErrPublisherClosed уже после возврата
+	dropped := int(r.dropped.Load() + r.retrying.Load())
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		logger.L.Warn("publish.drain.incomplete", zap.Int("dropped", dropped))
+	} else {
+		logger.L.Info("publish.drain.ok")
 	}
+	return dropped
 }
 
-// Close закрывает очередь
-func (r *RabbitPublisher) Close() {
-	close(r.queue)
+// publishErrorType метка error_type для RabbitMQMessagesFailed
+func publishErrorType(err error) string {
+	switch {
+	case errors.Is(err, infra.ErrRabbitNack):
+		return "nack"
+	case errors.Is(err, infra.ErrRabbitUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrPublisherQueueFull):
+		return "queue_full"
+	case errors.Is(err, ErrPublisherClosed):
+		return "closed"
+	default:
+		return "publish"
+	}
 }
@@ -0,0 +1,167 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBroker возвращает ошибки из errs по очереди, затем подтверждает
+type fakeBroker struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+	block bool
+	gate  chan struct{} // если задан, публикация ждёт его закрытия
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, _, _ string, msg amqp.Publishing) error {
+	b.mu.Lock()
+	b.calls++
+	block := b.block
+	var err error
+	if len(b.errs) > 0 {
+		err, b.errs = b.errs[0], b.errs[1:]
+	}
+	b.mu.Unlock()
+
+	if block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if b.gate != nil {
+		select {
+		case <-b.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (b *fakeBroker) Calls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+func TestRabbitPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name      string
+		broker    *fakeBroker
+		timeout   time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "acked",
+			broker:    &fakeBroker{},
+			timeout:   time.Second,
+			wantCalls: 1,
+		},
+		{
+			name:      "nack and lost connection are retried",
+			broker:    &fakeBroker{errs: []error{infra.ErrRabbitNack, infra.ErrRabbitUnavailable}},
+			timeout:   2 * time.Second,
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after timeout",
+			broker:    &fakeBroker{errs: []error{infra.ErrRabbitNack, infra.ErrRabbitNack, infra.ErrRabbitNack}},
+			timeout:   250 * time.Millisecond,
+			wantErr:   infra.ErrRabbitNack,
+			wantCalls: 2,
+		},
+		{
+			name:      "no confirm before timeout",
+			broker:    &fakeBroker{block: true},
+			timeout:   50 * time.Millisecond,
+			wantErr:   context.DeadlineExceeded,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := service.NewRabbitPublisher(tt.broker, 10, tt.timeout)
+			defer pub.Close(context.Background())
+
+			err := pub.Publish("subscriptions", "created", []byte(`{}`))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, tt.broker.Calls())
+		})
+	}
+}
+
+func TestRabbitPublisher_Closed(t *testing.T) {
+	pub := service.NewRabbitPublisher(&fakeBroker{}, 10, time.Second)
+	assert.Zero(t, pub.Close(context.Background()))
+
+	assert.ErrorIs(t, pub.Publish("subscriptions", "created", []byte(`{}`)), service.ErrPublisherClosed)
+}
+
+func TestRabbitPublisher_CloseDrain(t *testing.T) {
+	tests := []struct {
+		name        string
+		release     bool
+		closeAfter  time.Duration
+		wantDropped int
+		wantErr     error
+	}{
+		{
+			name:        "queued messages are flushed",
+			release:     true,
+			closeAfter:  time.Second,
+			wantDropped: 0,
+		},
+		{
+			name:        "deadline drops unsent messages",
+			closeAfter:  50 * time.Millisecond,
+			wantDropped: 3,
+			wantErr:     service.ErrPublisherClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := &fakeBroker{gate: make(chan struct{})}
+			pub := service.NewRabbitPublisher(broker, 10, 5*time.Second)
+
+			errs := make(chan error, 3)
+			for range 3 {
+				go func() { errs <- pub.Publish("subscriptions", "created", []byte(`{}`)) }()
+			}
+			// первое сообщение у брокера, остальные два ждут в очереди
+			assert.Eventually(t, func() bool { return broker.Calls() == 1 }, time.Second, 5*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.closeAfter)
+			defer cancel()
+			if tt.release {
+				time.AfterFunc(20*time.Millisecond, func() { close(broker.gate) })
+			}
+
+			assert.Equal(t, tt.wantDropped, pub.Close(ctx))
+			for range 3 {
+				err := <-errs
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			assert.ErrorIs(t, pub.Publish("subscriptions", "created", []byte(`{}`)), service.ErrPublisherClosed)
+		})
+	}
+}
@@ -3,9 +3,15 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/iokiris/efm-subscription-api/internal/events"
 	"github.com/iokiris/efm-subscription-api/internal/infra"
 	"github.com/iokiris/efm-subscription-api/internal/logger"
 	"github.com/iokiris/efm-subscription-api/internal/model"
@@ -14,21 +20,51 @@ import (
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrNotFound подписка не найдена или принадлежит другому пользователю
+	ErrNotFound = repo.ErrNotFound
+	// ErrInvalidSubscription некорректные поля подписки (ошибка клиента)
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrAlreadyPaused подписка уже приостановлена
+	ErrAlreadyPaused = repo.ErrAlreadyPaused
+	// ErrNotPaused подписка не приостановлена
+	ErrNotPaused = repo.ErrNotPaused
+	// ErrInvalidListQuery некорректные параметры списка или поиска подписок (фильтры, сортировка, курсор, запрос)
+	ErrInvalidListQuery = errors.New("invalid list query")
+)
+
+// Размер страницы списка подписок и результатов поиска
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// maxSearchQuery наибольшая длина поискового запроса в символах
+const maxSearchQuery = 100
+
+// eventsExchange exchange событий подписок
+const eventsExchange = "subscriptions"
+
 type SubscriptionService struct {
-	repo      repo.SubscriptionRepoInterface
-	redis     RedisInterface
-	publisher Publisher
-	ttl       time.Duration
-	metrics   *infra.Metrics
+	repo     repo.SubscriptionRepoInterface
+	redis    RedisInterface
+	outbox   repo.OutboxRepoInterface
+	webhooks WebhookQueue
+	eventLog EventLog
+	stream   Notifier
+	ttl      time.Duration
+	metrics  *infra.Metrics
 }
 
-func NewSubscriptionService(r repo.SubscriptionRepoInterface, redisClient RedisInterface, pub Publisher, ttl time.Duration) *SubscriptionService {
+// NewSubscriptionService создаёт сервис. События пишутся в outbox в одной транзакции с изменением подписки
+// и публикуются outbox.Relay; nil outbox — события не пишутся.
+func NewSubscriptionService(r repo.SubscriptionRepoInterface, redisClient RedisInterface, outbox repo.OutboxRepoInterface, ttl time.Duration) *SubscriptionService {
 	return &SubscriptionService{
-		repo:      r,
-		redis:     redisClient,
-		publisher: pub,
-		ttl:       ttl,
-		metrics:   nil, // будет установлено через SetMetrics
+		repo:    r,
+		redis:   redisClient,
+		outbox:  outbox,
+		ttl:     ttl,
+		metrics: nil, // будет установлено через SetMetrics
 	}
 }
 
@@ -37,16 +73,44 @@ func (s *SubscriptionService) SetMetrics(metrics *infra.Metrics) {
 	s.metrics = metrics
 }
 
+// SetWebhooks включает доставку событий в webhooks владельца подписки:
+// доставки создаются в той же транзакции, что и запись в outbox
+func (s *SubscriptionService) SetWebhooks(q WebhookQueue) {
+	s.webhooks = q
+}
+
+// SetEventLog включает запись событий в журнал (для replay) в той же транзакции, что и outbox
+func (s *SubscriptionService) SetEventLog(l EventLog) {
+	s.eventLog = l
+}
+
+// SetStream включает передачу событий в поток пользователя (SSE) после коммита изменения
+func (s *SubscriptionService) SetStream(n Notifier) {
+	s.stream = n
+}
+
 // -------------------- CRUD --------------------
 
 func (s *SubscriptionService) Create(ctx context.Context, sub *model.Subscription) error {
-	if err := s.repo.Create(ctx, sub); err != nil {
+	if err := sub.Normalize(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	var env *events.Envelope
+	err := s.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, sub); err != nil {
+			return err
+		}
+		var err error
+		env, err = s.emit(ctx, events.SubscriptionCreated{Subscription: *sub})
+		return err
+	})
+	if err != nil {
 		logger.L.Error("subscription.create.failed", zap.Error(err))
 		return err
 	}
+	s.notify(sub.UserID, env)
 
 	s.invalidateCache(ctx, sub.UserID)
-	s.publishEvent("subscriptions", "created", sub)
 
 	// Метрики
 	if s.metrics != nil {
@@ -62,14 +126,67 @@ func (s *SubscriptionService) Create(ctx context.Context, sub *model.Subscriptio
 	return nil
 }
 
-func (s *SubscriptionService) Update(ctx context.Context, sub *model.Subscription) error {
-	if err := s.repo.Update(ctx, sub); err != nil {
-		logger.L.Error("subscription.update.failed", zap.Error(err))
+// Update обновляет подписку. Непустой userID ограничивает обновление подписками этого пользователя.
+// Если цена изменилась, новая цена добавляется в историю с текущего месяца (или со start_date, если он позже),
+// поэтому суммы за прошлые месяцы не меняются. Событие updated содержит прежнее состояние и изменённые поля.
+func (s *SubscriptionService) Update(ctx context.Context, sub *model.Subscription, userID string) error {
+	if err := sub.Normalize(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+
+	var (
+		prev          *model.Subscription
+		effectiveFrom time.Time
+		priceChanged  bool
+		env           *events.Envelope
+	)
+	err := s.repo.InTx(ctx, func(ctx context.Context) error {
+		// строка заблокирована до коммита: параллельное обновление не подменит prev
+		var err error
+		if prev, err = s.repo.GetForUpdate(ctx, sub.ID, userID); err != nil {
+			return err
+		}
+		sub.UserID = prev.UserID
+		sub.Paused = prev.Paused
+		sub.CreatedAt = prev.CreatedAt
+
+		effectiveFrom = currentMonth()
+		if start := time.Time(sub.StartDate); start.After(effectiveFrom) {
+			effectiveFrom = start
+		}
+		priceChanged = sub.Price != prev.Price
+
+		if userID != "" {
+			err = s.repo.UpdateForUser(ctx, sub, userID)
+		} else {
+			err = s.repo.Update(ctx, sub)
+		}
+		if err != nil {
+			return err
+		}
+		if priceChanged {
+			if err := s.repo.AddPrice(ctx, sub.ID, effectiveFrom, sub.Price); err != nil {
+				return fmt.Errorf("add price: %w", err)
+			}
+		}
+		env, err = s.emit(ctx, events.NewSubscriptionUpdated(*prev, *sub))
 		return err
+	})
+	if err != nil {
+		logger.L.Error("subscription.update.failed", zap.Int64("id", sub.ID), zap.Error(err))
+		return err
+	}
+	s.notify(sub.UserID, env)
+
+	if priceChanged {
+		logger.L.Info("subscription.price.changed",
+			zap.Int64("id", sub.ID),
+			zap.Int("old", prev.Price),
+			zap.Int("new", sub.Price),
+			zap.Time("effective_from", effectiveFrom))
 	}
 
 	s.invalidateCache(ctx, sub.UserID)
-	s.publishEvent("subscriptions", "updated", sub)
 
 	// Метрики
 	if s.metrics != nil {
@@ -80,22 +197,33 @@ func (s *SubscriptionService) Update(ctx context.Context, sub *model.Subscriptio
 	return nil
 }
 
+// Delete удаляет подписку. Непустой userID ограничивает удаление подписками этого пользователя.
 func (s *SubscriptionService) Delete(ctx context.Context, id int64, userID string) error {
-	// Если userID не передан, попробуем получить из БД
-	if userID == "" {
-		sub, err := s.repo.GetByID(ctx, id)
-		if err == nil && sub != nil {
-			userID = sub.UserID
+	var env *events.Envelope
+	err := s.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if userID != "" {
+			err = s.repo.DeleteForUser(ctx, id, userID)
+		} else {
+			// userID не передан, попробуем получить из БД (нужен для сброса кеша)
+			if sub, getErr := s.repo.GetByID(ctx, id); getErr == nil && sub != nil {
+				userID = sub.UserID
+			}
+			err = s.repo.Delete(ctx, id)
 		}
-	}
-
-	if err := s.repo.Delete(ctx, id); err != nil {
-		logger.L.Error("subscription.delete.failed", zap.Error(err))
+		if err != nil {
+			return err
+		}
+		env, err = s.emit(ctx, events.SubscriptionDeleted{ID: id, UserID: userID})
+		return err
+	})
+	if err != nil {
+		logger.L.Error("subscription.delete.failed", zap.Int64("id", id), zap.Error(err))
 		return err
 	}
+	s.notify(userID, env)
 
 	s.invalidateCache(ctx, userID)
-	s.publishEvent("subscriptions", "deleted", map[string]any{"id": id, "user_id": userID})
 
 	// Метрики
 	if s.metrics != nil {
@@ -107,8 +235,9 @@ func (s *SubscriptionService) Delete(ctx context.Context, id int64, userID strin
 	return nil
 }
 
-func (s *SubscriptionService) Get(ctx context.Context, id int64) (*model.Subscription, error) {
-	sub, err := s.repo.GetByID(ctx, id)
+// Get возвращает подписку по ID. Непустой userID ограничивает поиск подписками этого пользователя.
+func (s *SubscriptionService) Get(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	sub, err := s.get(ctx, id, userID)
 	if err != nil {
 		logger.L.Error("subscription.get.failed", zap.Int64("id", id), zap.Error(err))
 		return nil, err
@@ -116,85 +245,432 @@ func (s *SubscriptionService) Get(ctx context.Context, id int64) (*model.Subscri
 	return sub, nil
 }
 
-// List возвращает список подписок пользователя
-func (s *SubscriptionService) List(ctx context.Context, userID string) ([]model.Subscription, error) {
-	subs, err := s.repo.List(ctx, userID)
+// Prices возвращает историю цен подписки. Непустой userID ограничивает поиск подписками этого пользователя.
+func (s *SubscriptionService) Prices(ctx context.Context, id int64, userID string) ([]model.SubscriptionPrice, error) {
+	if _, err := s.get(ctx, id, userID); err != nil {
+		logger.L.Error("subscription.prices.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	prices, err := s.repo.ListPrices(ctx, id)
+	if err != nil {
+		logger.L.Error("subscription.prices.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return prices, nil
+}
+
+// Pause приостанавливает подписку с месяца from (нулевой from — текущий месяц).
+// Непустой userID ограничивает операцию подписками этого пользователя.
+func (s *SubscriptionService) Pause(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error) {
+	sub, err := s.get(ctx, id, userID)
+	if err != nil {
+		logger.L.Error("subscription.pause.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	if from.IsZero() {
+		from = currentMonth()
+	}
+	if from.Before(time.Time(sub.StartDate)) || (sub.EndDate != nil && from.After(time.Time(*sub.EndDate))) {
+		return nil, fmt.Errorf("%w: pause must start within the subscription period", ErrInvalidSubscription)
+	}
+
+	var (
+		pause *model.SubscriptionPause
+		env   *events.Envelope
+	)
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if pause, err = s.repo.Pause(ctx, id, from); err != nil {
+			return err
+		}
+		env, err = s.emit(ctx, events.SubscriptionPaused{ID: id, UserID: sub.UserID, Pause: *pause})
+		return err
+	})
+	if err != nil {
+		logger.L.Error("subscription.pause.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	s.notify(sub.UserID, env)
+
+	s.invalidateCache(ctx, sub.UserID)
+
+	logger.L.Info("subscription.pause.ok", zap.Int64("id", id), zap.Time("from", from))
+	return pause, nil
+}
+
+// Resume завершает паузу: подписка снова оплачивается с месяца from.
+// Нулевой from — текущий месяц, а для ещё не начавшейся паузы — её начало (пауза отменяется).
+func (s *SubscriptionService) Resume(ctx context.Context, id int64, userID string, from time.Time) (*model.SubscriptionPause, error) {
+	sub, err := s.get(ctx, id, userID)
 	if err != nil {
-		logger.L.Error("subscription.list.failed", zap.String("user_id", userID), zap.Error(err))
+		logger.L.Error("subscription.resume.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	current, err := s.repo.OpenPause(ctx, id)
+	if err != nil {
+		logger.L.Error("subscription.resume.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	pausedFrom := time.Time(current.PausedFrom)
+	if from.IsZero() {
+		from = currentMonth()
+		if from.Before(pausedFrom) {
+			from = pausedFrom
+		}
+	}
+	if from.Before(pausedFrom) {
+		return nil, fmt.Errorf("%w: resume must not precede the pause start", ErrInvalidSubscription)
+	}
+
+	var (
+		pause *model.SubscriptionPause
+		env   *events.Envelope
+	)
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if pause, err = s.repo.Resume(ctx, current.ID, from); err != nil {
+			return err
+		}
+		env, err = s.emit(ctx, events.SubscriptionResumed{ID: id, UserID: sub.UserID, Pause: *pause})
+		return err
+	})
+	if err != nil {
+		logger.L.Error("subscription.resume.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	s.notify(sub.UserID, env)
+
+	s.invalidateCache(ctx, sub.UserID)
+
+	logger.L.Info("subscription.resume.ok", zap.Int64("id", id), zap.Time("from", from))
+	return pause, nil
+}
+
+// List возвращает страницу подписок пользователя q.UserID с фильтрами и сортировкой q.
+// NextCursor страницы передаётся в q.Cursor за следующей; курсор действует только для той же сортировки.
+func (s *SubscriptionService) List(ctx context.Context, q model.SubscriptionListQuery) (*model.SubscriptionPage, error) {
+	f, p, err := parseListQuery(q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+	}
+
+	// строка сверх лимита показывает, что есть следующая страница
+	limit := p.Limit
+	p.Limit++
+	subs, err := s.repo.List(ctx, f, p)
+	if err != nil {
+		logger.L.Error("subscription.list.failed", zap.String("user_id", f.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	page := &model.SubscriptionPage{Items: subs}
+	if len(subs) > limit {
+		page.Items = subs[:limit]
+		page.NextCursor = model.CursorAfter(p.Sort, p.Desc, subs[limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []model.Subscription{}
+	}
+	return page, nil
+}
+
+// Search ищет подписки userID по имени сервиса с опечатками и по части имени ("netflx", "yandex"),
+// наиболее похожие первыми. limit 0 — по умолчанию.
+func (s *SubscriptionService) Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error) {
+	query, limit, err := normalizeSearch(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.repo.Search(ctx, userID, query, limit)
+	if err != nil {
+		logger.L.Error("subscription.search.failed", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return matches, nil
+}
+
+// SuggestServices подсказывает имена сервисов, уже используемые в подписках всех пользователей,
+// для автодополнения по тем же правилам сходства, что и Search
+func (s *SubscriptionService) SuggestServices(ctx context.Context, query string, limit int) ([]string, error) {
+	query, limit, err := normalizeSearch(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.repo.SuggestServices(ctx, query, limit)
+	if err != nil {
+		logger.L.Error("subscription.suggest.failed", zap.Error(err))
+		return nil, err
+	}
+	return names, nil
+}
+
+// ListAll возвращает подписки всех пользователей с фильтрами (для администраторов)
+func (s *SubscriptionService) ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error) {
+	subs, err := s.repo.ListAll(ctx, f)
+	if err != nil {
+		logger.L.Error("subscription.list_all.failed",
+			zap.String("user_id", f.UserID),
+			zap.String("service", f.Service),
+			zap.Error(err))
 		return nil, err
 	}
 	return subs, nil
 }
 
-// -------------------- Summary --------------------
+// -------------------- Trials --------------------
 
-// GetSummary принимает строки from/to, парсит их в time.Time и вызывает repo.GetSummary.
-// Пустые from/to — означают "всё" Формат даты: 01-2005.
-func (s *SubscriptionService) GetSummary(ctx context.Context, userID string, serviceName string, from, to string) (int, error) {
-	key := fmt.Sprintf("summary:%s:%s:%s-%s", userID, serviceName, from, to)
+// NotifyTrialsEnding отправляет trial_ending для подписок, пробный период которых закончится в пределах notice.
+// Событие и отметка об уведомлении пишутся в одной транзакции, поэтому неудачные попытки повторятся.
+// Возвращает число отправленных уведомлений.
+func (s *SubscriptionService) NotifyTrialsEnding(ctx context.Context, notice time.Duration) (int, error) {
+	subs, err := s.repo.ListTrialsEnding(ctx, time.Now().UTC().Add(notice))
+	if err != nil {
+		logger.L.Error("trial.ending.list_failed", zap.Error(err))
+		return 0, err
+	}
 
-	// кеш — если есть, вернуть
-	if s.redis != nil {
-		if val, err := s.redis.Get(ctx, key).Result(); err == nil {
-			var total int
-			if _, err := fmt.Sscanf(val, "%d", &total); err == nil {
-				logger.L.Debug("summary.cache.hit",
-					zap.String("user_id", userID),
-					zap.String("service", serviceName),
-				)
-				return total, nil
+	sent := 0
+	for i := range subs {
+		sub := &subs[i]
+		var env *events.Envelope
+		err := s.repo.InTx(ctx, func(ctx context.Context) error {
+			var err error
+			if env, err = s.emit(ctx, events.TrialEnding{Subscription: *sub}); err != nil {
+				return err
+			}
+			return s.repo.MarkTrialNotified(ctx, sub.ID)
+		})
+		if err != nil {
+			logger.L.Error("trial.ending.failed", zap.Int64("id", sub.ID), zap.Error(err))
+			continue
+		}
+		s.notify(sub.UserID, env)
+		sent++
+		logger.L.Info("trial.ending.notified",
+			zap.Int64("id", sub.ID),
+			zap.String("user_id", sub.UserID),
+			zap.Time("trial_end", time.Time(*sub.TrialEnd)))
+	}
+	return sent, nil
+}
+
+// -------------------- Lifecycle --------------------
+
+// ExpireEnded отправляет expired для подписок, чей end_date прошёл, и отмечает их истёкшими
+// (в одной транзакции, как NotifyTrialsEnding). Возвращает число истёкших подписок.
+func (s *SubscriptionService) ExpireEnded(ctx context.Context) (int, error) {
+	subs, err := s.repo.ListExpired(ctx)
+	if err != nil {
+		logger.L.Error("subscription.expire.list_failed", zap.Error(err))
+		return 0, err
+	}
+
+	expired := 0
+	for i := range subs {
+		sub := &subs[i]
+		var env *events.Envelope
+		err := s.repo.InTx(ctx, func(ctx context.Context) error {
+			var err error
+			if env, err = s.emit(ctx, events.SubscriptionExpired{Subscription: *sub}); err != nil {
+				return err
 			}
+			return s.repo.MarkExpired(ctx, sub.ID)
+		})
+		if err != nil {
+			logger.L.Error("subscription.expire.failed", zap.Int64("id", sub.ID), zap.Error(err))
+			continue
+		}
+		s.notify(sub.UserID, env)
+		expired++
+		logger.L.Info("subscription.expire.ok", zap.Int64("id", sub.ID), zap.String("user_id", sub.UserID))
+	}
+	return expired, nil
+}
+
+// NotifyRenewalsDue отправляет renewal_due для подписок, у которых наступила дата очередного списания.
+// Повторно о той же дате не уведомляет. Возвращает число отправленных уведомлений.
+func (s *SubscriptionService) NotifyRenewalsDue(ctx context.Context) (int, error) {
+	due, err := s.repo.ListRenewalsDue(ctx)
+	if err != nil {
+		logger.L.Error("subscription.renewal.list_failed", zap.Error(err))
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		r := &due[i]
+		var env *events.Envelope
+		err := s.repo.InTx(ctx, func(ctx context.Context) error {
+			var err error
+			if env, err = s.emit(ctx, events.RenewalDue{Subscription: r.Subscription, DueOn: r.DueOn}); err != nil {
+				return err
+			}
+			return s.repo.MarkRenewalNotified(ctx, r.Subscription.ID, time.Time(r.DueOn))
+		})
+		if err != nil {
+			logger.L.Error("subscription.renewal.failed", zap.Int64("id", r.Subscription.ID), zap.Error(err))
+			continue
+		}
+		s.notify(r.Subscription.UserID, env)
+		sent++
+		logger.L.Info("subscription.renewal.notified",
+			zap.Int64("id", r.Subscription.ID),
+			zap.String("user_id", r.Subscription.UserID),
+			zap.Time("due_on", time.Time(r.DueOn)))
+	}
+	return sent, nil
+}
+
+// -------------------- Summary --------------------
+
+// summaryKeyMonth формат месяца в ключе кеша сумм
+const summaryKeyMonth = "2006-01"
+
+// GetSummary принимает строки from/to, парсит их в time.Time и вызывает repo.GetSummary.
+// Пустой from — с начала подписок, пустой to — по текущий месяц. Формат даты: 01-2005.
+// Пустой UserID — сумма по всем пользователям (для администраторов).
+// Непустой GroupBy добавляет в ответ разбивку по сервисам и/или месяцам.
+func (s *SubscriptionService) GetSummary(ctx context.Context, q model.SummaryQuery) (*model.Summary, error) {
+	groupBy, err := normalizeGroupBy(q.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	currency := model.DefaultCurrency
+	if q.Currency != "" {
+		if currency, err = model.NormalizeCurrency(q.Currency); err != nil {
+			return nil, err
 		}
 	}
 
 	// парсим даты
-	fromT, toT, err := normalizeRangeMY(from, to)
+	fromT, toT, err := normalizeRangeMY(q.From, q.To)
 	logger.L.Debug("summary.range",
 		zap.String("fromMY", fromT.String()),
 		zap.String("toMY", toT.String()))
 
 	if err != nil {
 		logger.L.Error("summary.parse_dates.failed",
-			zap.String("from", from), zap.String("to", to), zap.Error(err))
-		return 0, err
+			zap.String("from", q.From), zap.String("to", q.To), zap.Error(err))
+		return nil, err
+	}
+
+	// ключ из разобранных месяцев: пустой to в разные месяцы — разные диапазоны
+	key := fmt.Sprintf("summary:%s:%s:%s-%s:%s:%s", q.UserID, q.Service,
+		fromT.Format(summaryKeyMonth), toT.Format(summaryKeyMonth), currency, strings.Join(groupBy, ","))
+
+	// кеш — если есть, вернуть
+	if s.redis != nil {
+		if val, err := s.redis.Get(ctx, key).Result(); err == nil {
+			var cached model.Summary
+			if err := json.Unmarshal([]byte(val), &cached); err == nil {
+				logger.L.Debug("summary.cache.hit",
+					zap.String("user_id", q.UserID),
+					zap.String("service", q.Service),
+				)
+				return &cached, nil
+			}
+		}
 	}
 
-	total, err := s.repo.GetSummary(ctx, userID, serviceName, fromT, toT)
+	f := model.SummaryFilter{UserID: q.UserID, Service: q.Service, From: fromT, To: toT, Currency: currency}
+
+	summary, err := s.repo.GetSummary(ctx, f)
 	if err != nil {
 		logger.L.Error("summary.query.failed", zap.Error(err))
-		return 0, err
+		return nil, err
+	}
+	if len(groupBy) > 0 {
+		if summary.Breakdown, err = s.summaryBreakdown(ctx, f, groupBy); err != nil {
+			logger.L.Error("summary.breakdown.failed", zap.Strings("group_by", groupBy), zap.Error(err))
+			return nil, err
+		}
 	}
 
 	// записать в кеш (если есть)
 	if s.redis != nil {
-		if err := s.redis.Set(ctx, key, total, s.ttl).Err(); err != nil {
-			logger.L.Warn("summary.cache.set_failed", zap.String("key", key), zap.Error(err))
+		if data, err := json.Marshal(summary); err == nil {
+			if err := s.redis.Set(ctx, key, data, s.ttl).Err(); err != nil {
+				logger.L.Warn("summary.cache.set_failed", zap.String("key", key), zap.Error(err))
+			}
 		}
 	}
 
 	// Метрики
 	if s.metrics != nil {
-		s.metrics.SubscriptionsSummary.WithLabelValues(serviceName).Observe(float64(total))
+		s.metrics.SubscriptionsSummary.WithLabelValues(q.Service).Observe(float64(summary.Total))
+	}
+
+	if len(summary.MissingRates) > 0 {
+		logger.L.Warn("summary.rates.missing",
+			zap.String("currency", currency),
+			zap.Int("count", len(summary.MissingRates)))
 	}
 
 	logger.L.Info("summary.ok",
-		zap.String("user_id", userID),
-		zap.String("service", serviceName),
-		zap.Int("total", total),
+		zap.String("user_id", q.UserID),
+		zap.String("service", q.Service),
+		zap.String("currency", currency),
+		zap.Int("total", summary.Total),
 	)
-	return total, nil
+	return summary, nil
+}
+
+// summaryBreakdown собирает разбивку: service — суммы по сервисам, month — помесячный ряд,
+// вместе — дополнительно помесячный ряд внутри каждого сервиса.
+func (s *SubscriptionService) summaryBreakdown(ctx context.Context, f model.SummaryFilter, groupBy []string) (*model.SummaryBreakdown, error) {
+	var (
+		b         model.SummaryBreakdown
+		err       error
+		byService = slices.Contains(groupBy, model.GroupByService)
+		byMonth   = slices.Contains(groupBy, model.GroupByMonth)
+	)
+	switch {
+	case byService && byMonth:
+		b.ByService, err = s.repo.SummaryByServiceMonth(ctx, f)
+	case byService:
+		b.ByService, err = s.repo.SummaryByService(ctx, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if byMonth {
+		if b.ByMonth, err = s.repo.SummaryByMonth(ctx, f); err != nil {
+			return nil, err
+		}
+	}
+	return &b, nil
 }
 
 // -------------------- Helpers --------------------
 
+// get загружает подписку, с непустым userID — только подписку этого пользователя
+func (s *SubscriptionService) get(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	if userID != "" {
+		return s.repo.GetByIDForUser(ctx, id, userID)
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// invalidateCache сбрасывает суммы пользователя и суммы по всем пользователям (ключ с пустым userID)
 func (s *SubscriptionService) invalidateCache(ctx context.Context, userID string) {
 	if s.redis == nil {
 		return
 	}
-	pattern := fmt.Sprintf("summary:%s:*", userID)
-	iter := s.redis.Scan(ctx, 0, pattern, 0).Iterator()
+	patterns := []string{fmt.Sprintf("summary:%s:*", userID)}
+	if userID != "" {
+		patterns = append(patterns, "summary::*")
+	}
+	for _, pattern := range patterns {
+		deleteKeys(ctx, s.redis, pattern)
+	}
+}
+
+// deleteKeys удаляет ключи Redis по шаблону
+func deleteKeys(ctx context.Context, r RedisInterface, pattern string) {
+	iter := r.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
-		if err := s.redis.Del(ctx, iter.Val()).Err(); err != nil {
+		if err := r.Del(ctx, iter.Val()).Err(); err != nil {
 			logger.L.Warn("cache.del.failed",
 				zap.String("key", iter.Val()),
 				zap.Error(err))
@@ -202,23 +678,55 @@ func (s *SubscriptionService) invalidateCache(ctx context.Context, userID string
 	}
 }
 
-func (s *SubscriptionService) publishEvent(exchange, routingKey string, payload interface{}) {
-	if s.publisher == nil {
-		return
+// emit записывает событие в outbox, журнал событий и доставки webhooks в конверте CloudEvents. Вызывается внутри repo.InTx,
+// чтобы событие сохранилось в той же транзакции, что и изменение, и не потерялось при сбое.
+// Возвращает конверт для notify после коммита (nil, если событиям некуда уходить).
+func (s *SubscriptionService) emit(ctx context.Context, e events.Event) (*events.Envelope, error) {
+	if s.outbox == nil && s.webhooks == nil && s.eventLog == nil && s.stream == nil {
+		return nil, nil
 	}
 
-	data, err := json.Marshal(payload)
+	env, err := events.New(ctx, e)
 	if err != nil {
-		logger.L.Error("publish.marshal.failed", zap.Error(err))
-		return
+		return nil, err
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s event: %w", e.Type(), err)
+	}
+	if s.outbox != nil {
+		if err := s.outbox.Add(ctx, &model.OutboxEvent{Exchange: eventsExchange, RoutingKey: e.RoutingKey(), Payload: data}); err != nil {
+			return nil, fmt.Errorf("outbox %s event: %w", e.Type(), err)
+		}
+	}
+	if s.eventLog != nil {
+		entry := &model.EventLogEntry{
+			EventID:    env.ID,
+			Type:       env.Type,
+			Exchange:   eventsExchange,
+			RoutingKey: e.RoutingKey(),
+			UserID:     e.Owner(),
+			Subject:    env.Subject,
+			Payload:    data,
+		}
+		if err := s.eventLog.Add(ctx, entry); err != nil {
+			return nil, fmt.Errorf("event log %s event: %w", e.Type(), err)
+		}
 	}
+	if s.webhooks != nil && e.Owner() != "" {
+		if _, err := s.webhooks.Enqueue(ctx, e.Owner(), env.Type, env.ID, data); err != nil {
+			return nil, fmt.Errorf("webhooks %s event: %w", e.Type(), err)
+		}
+	}
+	return env, nil
+}
 
-	if err := s.publisher.Publish(exchange, routingKey, data); err != nil {
-		logger.L.Error("publish.failed",
-			zap.String("exchange", exchange),
-			zap.String("routing_key", routingKey),
-			zap.Error(err))
+// notify передаёт закоммиченное событие в поток пользователя
+func (s *SubscriptionService) notify(userID string, env *events.Envelope) {
+	if s.stream == nil || env == nil {
+		return
 	}
+	s.stream.Notify(userID, env)
 }
 
 // normalizeRangeMY парсит строки в time.Time
@@ -236,7 +744,8 @@ func normalizeRangeMY(from, to string) (time.Time, time.Time, error) {
 	}
 
 	if to == "" {
-		toMY = model.MonthYear(time.Date(9999, 12, 1, 0, 0, 0, 0, time.UTC)) // far future, первый день месяца
+		// текущий месяц: бессрочные подписки оплачиваются помесячно, будущие месяцы ещё не списаны
+		toMY = model.MonthYear(currentMonth())
 	} else {
 		toMY, err = parseMonthYear(to)
 		if err != nil {
@@ -247,6 +756,124 @@ func normalizeRangeMY(from, to string) (time.Time, time.Time, error) {
 	return time.Time(fromMY), time.Time(toMY), nil
 }
 
+// normalizeGroupBy проверяет group_by и приводит к порядку service, month (для стабильного ключа кеша)
+func normalizeGroupBy(groupBy []string) ([]string, error) {
+	var out []string
+	for _, g := range []string{model.GroupByService, model.GroupByMonth} {
+		if slices.Contains(groupBy, g) {
+			out = append(out, g)
+		}
+	}
+	for _, g := range groupBy {
+		if g != model.GroupByService && g != model.GroupByMonth {
+			return nil, fmt.Errorf("invalid group_by %q, expect service, month or service,month", g)
+		}
+	}
+	return out, nil
+}
+
+// parseListQuery разбирает параметры списка в фильтр и страницу
+func parseListQuery(q model.SubscriptionListQuery) (model.SubscriptionFilter, model.SubscriptionPageRequest, error) {
+	f := model.SubscriptionFilter{UserID: q.UserID, Service: q.Service, Trial: q.Trial, Status: model.Status(q.Status)}
+	p := model.SubscriptionPageRequest{Sort: model.SortCreatedAt, Limit: defaultPageLimit}
+
+	if !f.Status.Valid() {
+		return f, p, fmt.Errorf("invalid status %q, expect active, paused or ended", q.Status)
+	}
+	if q.ActiveOn != "" {
+		on, err := time.Parse(model.DateLayout, q.ActiveOn)
+		if err != nil {
+			my, myErr := parseMonthYear(q.ActiveOn)
+			if myErr != nil {
+				return f, p, fmt.Errorf("invalid active_on %q, expect YYYY-MM-DD or MM-YYYY", q.ActiveOn)
+			}
+			on = time.Time(my)
+		}
+		f.ActiveOn = &on
+	}
+	var err error
+	if f.PriceMin, err = parsePriceBound("price_min", q.PriceMin); err != nil {
+		return f, p, err
+	}
+	if f.PriceMax, err = parsePriceBound("price_max", q.PriceMax); err != nil {
+		return f, p, err
+	}
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		return f, p, errors.New("price_min must not exceed price_max")
+	}
+
+	if q.Sort != "" {
+		p.Sort = model.SubscriptionSort(q.Sort)
+		if !p.Sort.Valid() {
+			return f, p, fmt.Errorf("invalid sort %q, expect created_at, price, start_date or service_name", q.Sort)
+		}
+	}
+	switch q.Order {
+	case "":
+		// новые подписки первыми, остальные поля — по возрастанию
+		p.Desc = p.Sort == model.SortCreatedAt
+	case "asc":
+	case "desc":
+		p.Desc = true
+	default:
+		return f, p, fmt.Errorf("invalid order %q, expect asc or desc", q.Order)
+	}
+	if q.Limit != "" {
+		limit, err := strconv.Atoi(q.Limit)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return f, p, fmt.Errorf("invalid limit %q, expect 1..%d", q.Limit, maxPageLimit)
+		}
+		p.Limit = limit
+	}
+	if q.Cursor != "" {
+		after, err := model.ParseSubscriptionCursor(q.Cursor)
+		if err != nil {
+			return f, p, err
+		}
+		if after.Sort != p.Sort || after.Desc != p.Desc {
+			return f, p, errors.New("cursor was issued for a different sort")
+		}
+		p.After = after
+	}
+	return f, p, nil
+}
+
+// normalizeSearch проверяет поисковый запрос и подставляет limit по умолчанию
+func normalizeSearch(query string, limit int) (string, int, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", 0, fmt.Errorf("%w: q is required", ErrInvalidListQuery)
+	}
+	if utf8.RuneCountInString(query) > maxSearchQuery {
+		return "", 0, fmt.Errorf("%w: q is longer than %d characters", ErrInvalidListQuery, maxSearchQuery)
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit < 1 || limit > maxPageLimit {
+		return "", 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, maxPageLimit)
+	}
+	return query, limit, nil
+}
+
+// parsePriceBound граница цены; пустая строка — без границы
+func parsePriceBound(name, value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid %s %q, expect non-negative integer", name, value)
+	}
+	return &v, nil
+}
+
+// currentMonth первое число текущего месяца (UTC)
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
 func parseMonthYear(s string) (model.MonthYear, error) {
 	var my model.MonthYear
 	if err := my.UnmarshalJSON([]byte(`"` + s + `"`)); err != nil {
@@ -2,16 +2,21 @@ package service_test
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/iokiris/efm-subscription-api/internal/events"
 	"github.com/iokiris/efm-subscription-api/internal/logger"
 
 	"github.com/iokiris/efm-subscription-api/internal/model"
 	"github.com/iokiris/efm-subscription-api/internal/service"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -33,35 +38,199 @@ func (m *MockRepo) Update(ctx context.Context, sub *model.Subscription) error {
 	return m.Called(ctx, sub).Error(0)
 }
 
+func (m *MockRepo) UpdateForUser(ctx context.Context, sub *model.Subscription, userID string) error {
+	return m.Called(ctx, sub, userID).Error(0)
+}
+
 func (m *MockRepo) Delete(ctx context.Context, id int64) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *MockRepo) DeleteForUser(ctx context.Context, id int64, userID string) error {
+	return m.Called(ctx, id, userID).Error(0)
+}
+
 func (m *MockRepo) GetByID(ctx context.Context, id int64) (*model.Subscription, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*model.Subscription), args.Error(1)
 }
 
-func (m *MockRepo) List(ctx context.Context, userID string) ([]model.Subscription, error) {
-	args := m.Called(ctx, userID)
+func (m *MockRepo) GetByIDForUser(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Subscription), args.Error(1)
+}
+
+func (m *MockRepo) GetForUpdate(ctx context.Context, id int64, userID string) (*model.Subscription, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Subscription), args.Error(1)
+}
+
+func (m *MockRepo) List(ctx context.Context, f model.SubscriptionFilter, p model.SubscriptionPageRequest) ([]model.Subscription, error) {
+	args := m.Called(ctx, f, p)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (m *MockRepo) ListAll(ctx context.Context, f model.SubscriptionFilter) ([]model.Subscription, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (m *MockRepo) Search(ctx context.Context, userID, query string, limit int) ([]model.SubscriptionMatch, error) {
+	args := m.Called(ctx, userID, query, limit)
+	return args.Get(0).([]model.SubscriptionMatch), args.Error(1)
+}
+
+func (m *MockRepo) SuggestServices(ctx context.Context, query string, limit int) ([]string, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepo) AddPrice(ctx context.Context, id int64, effectiveFrom time.Time, price int) error {
+	return m.Called(ctx, id, effectiveFrom, price).Error(0)
+}
+
+func (m *MockRepo) ListPrices(ctx context.Context, id int64) ([]model.SubscriptionPrice, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.SubscriptionPrice), args.Error(1)
+}
+
+func (m *MockRepo) ListTrialsEnding(ctx context.Context, until time.Time) ([]model.Subscription, error) {
+	args := m.Called(ctx, until)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (m *MockRepo) MarkTrialNotified(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRepo) ListExpired(ctx context.Context) ([]model.Subscription, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]model.Subscription), args.Error(1)
 }
 
-func (m *MockRepo) GetSummary(ctx context.Context, userID, serviceName string, from, to time.Time) (int, error) {
-	args := m.Called(ctx, userID, serviceName, from, to)
-	return args.Int(0), args.Error(1)
+func (m *MockRepo) MarkExpired(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRepo) ListRenewalsDue(ctx context.Context) ([]model.RenewalDue, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.RenewalDue), args.Error(1)
+}
+
+func (m *MockRepo) MarkRenewalNotified(ctx context.Context, id int64, dueOn time.Time) error {
+	return m.Called(ctx, id, dueOn).Error(0)
+}
+
+func (m *MockRepo) OpenPause(ctx context.Context, id int64) (*model.SubscriptionPause, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPause), args.Error(1)
+}
+
+func (m *MockRepo) Pause(ctx context.Context, id int64, from time.Time) (*model.SubscriptionPause, error) {
+	args := m.Called(ctx, id, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPause), args.Error(1)
+}
+
+func (m *MockRepo) Resume(ctx context.Context, pauseID int64, from time.Time) (*model.SubscriptionPause, error) {
+	args := m.Called(ctx, pauseID, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SubscriptionPause), args.Error(1)
+}
+
+func (m *MockRepo) GetSummary(ctx context.Context, f model.SummaryFilter) (*model.Summary, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Summary), args.Error(1)
+}
+
+func (m *MockRepo) SummaryByService(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.ServiceTotal), args.Error(1)
+}
+
+func (m *MockRepo) SummaryByMonth(ctx context.Context, f model.SummaryFilter) ([]model.MonthTotal, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.MonthTotal), args.Error(1)
+}
+
+func (m *MockRepo) SummaryByServiceMonth(ctx context.Context, f model.SummaryFilter) ([]model.ServiceTotal, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]model.ServiceTotal), args.Error(1)
+}
+
+// InTx выполняет fn без транзакции
+func (m *MockRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type MockOutbox struct {
+	mock.Mock
+}
+
+func (m *MockOutbox) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockOutbox) Add(ctx context.Context, e *model.OutboxEvent) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockOutbox) ListPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]model.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutbox) MarkSent(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockOutbox) MarkFailed(ctx context.Context, id int64, cause error, nextAttempt time.Time) error {
+	return m.Called(ctx, id, cause, nextAttempt).Error(0)
+}
+
+func (m *MockOutbox) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-type MockPublisher struct {
+// outboxEvent матчер события routingKey, payload которого содержит contains
+func outboxEvent(routingKey, contains string) interface{} {
+	return mock.MatchedBy(func(e *model.OutboxEvent) bool {
+		return e.Exchange == "subscriptions" && e.RoutingKey == routingKey && strings.Contains(string(e.Payload), contains)
+	})
+}
+
+type MockWebhookQueue struct {
 	mock.Mock
 }
 
-func (m *MockPublisher) Publish(exchange, routingKey string, body []byte) error {
-	return m.Called(exchange, routingKey, body).Error(0)
+func (m *MockWebhookQueue) Enqueue(ctx context.Context, userID, eventType, eventID string, payload []byte) (int64, error) {
+	args := m.Called(ctx, userID, eventType, eventID, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockNotifier struct {
+	mock.Mock
 }
 
-func (m *MockPublisher) Close() {
-	m.Called()
+func (m *MockNotifier) Notify(userID string, env *events.Envelope) {
+	m.Called(userID, env)
 }
 
 // -------------------- Tests --------------------
@@ -69,56 +238,219 @@ func (m *MockPublisher) Close() {
 func TestSubscriptionService_Create(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepo)
-	mockPub := new(MockPublisher)
+	mockOutbox := new(MockOutbox)
 
-	svc := service.NewSubscriptionService(mockRepo, nil, mockPub, time.Minute)
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
 	sub := &model.Subscription{ID: 1, UserID: "user1", Service: "test_service"}
 
 	mockRepo.On("Create", ctx, sub).Return(nil)
-	mockPub.On("Publish", "subscriptions", "created", mock.Anything).Return(nil)
+	mockOutbox.On("Add", ctx, outboxEvent("created", `"type":"subscription.created.v1"`)).Return(nil)
 
 	err := svc.Create(ctx, sub)
 	assert.NoError(t, err)
+	assert.Equal(t, model.BillingMonth, sub.BillingPeriod)
+	assert.Equal(t, 1, sub.BillingInterval)
 
 	mockRepo.AssertCalled(t, "Create", ctx, sub)
-	mockPub.AssertCalled(t, "Publish", "subscriptions", "created", mock.Anything)
+	mockOutbox.AssertCalled(t, "Add", ctx, outboxEvent("created", `"type":"subscription.created.v1"`))
+}
+
+func TestSubscriptionService_Create_OutboxFailure(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+	sub := &model.Subscription{UserID: "user1", Service: "test_service"}
+
+	mockRepo.On("Create", ctx, sub).Return(nil)
+	mockOutbox.On("Add", ctx, outboxEvent("created", `"type":"subscription.created.v1"`)).Return(errors.New("connection reset"))
+
+	// без события транзакция откатывается, и создание не считается успешным
+	err := svc.Create(ctx, sub)
+	assert.Error(t, err)
+}
+
+func TestSubscriptionService_Stream(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	notifier := new(MockNotifier)
+
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+	svc.SetStream(notifier)
+	ok := &model.Subscription{UserID: "user1", Service: "ok"}
+	failed := &model.Subscription{UserID: "user1", Service: "failed"}
+
+	var outboxPayload []byte
+	mockRepo.On("Create", ctx, ok).Return(nil)
+	mockRepo.On("Create", ctx, failed).Return(errors.New("unique violation"))
+	mockOutbox.On("Add", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		outboxPayload = args.Get(1).(*model.OutboxEvent).Payload
+	}).Return(nil)
+	notifier.On("Notify", "user1", mock.Anything).Return()
+
+	require.NoError(t, svc.Create(ctx, ok))
+	require.Error(t, svc.Create(ctx, failed))
+
+	// в поток попадает только закоммиченное событие, с тем же id, что и в outbox
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+	env := notifier.Calls[0].Arguments.Get(1).(*events.Envelope)
+	id, _ := events.Peek(outboxPayload)
+	assert.Equal(t, id, env.ID)
+	assert.Equal(t, "subscription.created.v1", env.Type)
+}
+
+func TestSubscriptionService_Create_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	mockWebhooks := new(MockWebhookQueue)
+
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+	svc.SetWebhooks(mockWebhooks)
+	sub := &model.Subscription{UserID: "user1", Service: "test_service"}
+
+	var outboxPayload []byte
+	mockRepo.On("Create", ctx, sub).Return(nil)
+	mockOutbox.On("Add", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		outboxPayload = args.Get(1).(*model.OutboxEvent).Payload
+	}).Return(nil)
+	mockWebhooks.On("Enqueue", ctx, "user1", "subscription.created.v1", mock.AnythingOfType("string"), mock.Anything).
+		Return(int64(2), nil)
+
+	require.NoError(t, svc.Create(ctx, sub))
+
+	// webhooks получают тот же конверт, что и брокер
+	call := mockWebhooks.Calls[0]
+	assert.Equal(t, outboxPayload, call.Arguments.Get(4))
+	id, _ := events.Peek(call.Arguments.Get(4).([]byte))
+	assert.Equal(t, id, call.Arguments.Get(3))
+}
+
+func TestSubscriptionService_Create_EventLog(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	mockLog := new(MockEventLogRepo)
+
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+	svc.SetEventLog(mockLog)
+	sub := &model.Subscription{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", Service: "test_service"}
+
+	var outboxPayload []byte
+	mockRepo.On("Create", ctx, sub).Return(nil)
+	mockOutbox.On("Add", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		outboxPayload = args.Get(1).(*model.OutboxEvent).Payload
+	}).Return(nil)
+	mockLog.On("Add", ctx, mock.Anything).Return(nil)
+
+	require.NoError(t, svc.Create(ctx, sub))
+
+	// журнал хранит тот же конверт и маршрут, что уходит в брокер
+	entry := mockLog.Calls[0].Arguments.Get(1).(*model.EventLogEntry)
+	id, subject := events.Peek(entry.Payload)
+	assert.Equal(t, outboxPayload, []byte(entry.Payload))
+	assert.Equal(t, id, entry.EventID)
+	assert.Equal(t, subject, entry.Subject)
+	assert.Equal(t, "subscription.created.v1", entry.Type)
+	assert.Equal(t, "subscriptions", entry.Exchange)
+	assert.Equal(t, "created", entry.RoutingKey)
+	assert.Equal(t, sub.UserID, entry.UserID)
+}
+
+func TestSubscriptionService_Create_InvalidBilling(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  *model.Subscription
+	}{
+		{"unknown period", &model.Subscription{UserID: "user1", BillingPeriod: "day"}},
+		{"negative interval", &model.Subscription{UserID: "user1", BillingPeriod: model.BillingYear, BillingInterval: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+			err := svc.Create(context.Background(), tt.sub)
+			assert.ErrorIs(t, err, service.ErrInvalidSubscription)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestSubscriptionService_Update(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepo)
-	mockPub := new(MockPublisher)
+	mockOutbox := new(MockOutbox)
 
-	svc := service.NewSubscriptionService(mockRepo, nil, mockPub, time.Minute)
-	sub := &model.Subscription{ID: 1, UserID: "user1", Service: "service"}
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+	sub := &model.Subscription{ID: 1, Service: "service", Price: 100}
 
+	mockRepo.On("GetForUpdate", ctx, int64(1), "").Return(&model.Subscription{ID: 1, UserID: "user1", Service: "old", Price: 100}, nil)
 	mockRepo.On("Update", ctx, sub).Return(nil)
-	mockPub.On("Publish", "subscriptions", "updated", mock.Anything).Return(nil)
+	mockOutbox.On("Add", ctx, outboxEvent("updated", `"service_name":{"from":"old","to":"service"}`)).Return(nil)
 
-	err := svc.Update(ctx, sub)
+	err := svc.Update(ctx, sub, "")
 	assert.NoError(t, err)
+	assert.Equal(t, "user1", sub.UserID)
 
 	mockRepo.AssertCalled(t, "Update", ctx, sub)
-	mockPub.AssertCalled(t, "Publish", "subscriptions", "updated", mock.Anything)
+	mockRepo.AssertNotCalled(t, "AddPrice", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockOutbox.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Update_PriceHistory(t *testing.T) {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	past := model.MonthYear(thisMonth.AddDate(-1, 0, 0))
+	future := model.MonthYear(thisMonth.AddDate(0, 2, 0))
+
+	tests := []struct {
+		name          string
+		startDate     model.MonthYear
+		effectiveFrom time.Time
+	}{
+		{"started subscription changes from current month", past, thisMonth},
+		{"future subscription changes from start", future, time.Time(future)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(MockRepo)
+			svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+			sub := &model.Subscription{ID: 1, Service: "s", Price: 500, StartDate: tt.startDate}
+			mockRepo.On("GetForUpdate", ctx, int64(1), "user1").
+				Return(&model.Subscription{ID: 1, UserID: "user1", Price: 400, StartDate: tt.startDate}, nil)
+			mockRepo.On("UpdateForUser", ctx, sub, "user1").Return(nil)
+			mockRepo.On("AddPrice", ctx, int64(1), tt.effectiveFrom, 500).Return(nil)
+
+			assert.NoError(t, svc.Update(ctx, sub, "user1"))
+			mockRepo.AssertExpectations(t)
+		})
+	}
 }
 
 func TestSubscriptionService_Delete(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepo)
-	mockPub := new(MockPublisher)
+	mockOutbox := new(MockOutbox)
 
-	svc := service.NewSubscriptionService(mockRepo, nil, mockPub, time.Minute)
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
 
 	sub := &model.Subscription{ID: 1, UserID: "user1"}
 	mockRepo.On("GetByID", ctx, int64(1)).Return(sub, nil)
 	mockRepo.On("Delete", ctx, int64(1)).Return(nil)
-	mockPub.On("Publish", "subscriptions", "deleted", mock.Anything).Return(nil)
+	mockOutbox.On("Add", ctx, outboxEvent("deleted", `"type":"subscription.deleted.v1"`)).Return(nil)
 
 	err := svc.Delete(ctx, 1, "")
 	assert.NoError(t, err)
 
 	mockRepo.AssertCalled(t, "Delete", ctx, int64(1))
-	mockPub.AssertCalled(t, "Publish", "subscriptions", "deleted", mock.Anything)
+	mockOutbox.AssertCalled(t, "Add", ctx, outboxEvent("deleted", `"type":"subscription.deleted.v1"`))
 }
 
 func TestSubscriptionService_Get(t *testing.T) {
@@ -129,22 +461,319 @@ func TestSubscriptionService_Get(t *testing.T) {
 	sub := &model.Subscription{ID: 1, UserID: "user1", Service: "s"}
 	mockRepo.On("GetByID", ctx, int64(1)).Return(sub, nil)
 
-	result, err := svc.Get(ctx, 1)
+	result, err := svc.Get(ctx, 1, "")
 	assert.NoError(t, err)
 	assert.Equal(t, sub, result)
 }
 
+func TestSubscriptionService_OwnerScoped(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+	mockRepo.On("GetByIDForUser", ctx, int64(1), "user2").Return(nil, service.ErrNotFound)
+	mockRepo.On("GetForUpdate", ctx, int64(1), "user2").Return(nil, service.ErrNotFound)
+	mockRepo.On("DeleteForUser", ctx, int64(1), "user2").Return(service.ErrNotFound)
+	sub := &model.Subscription{ID: 1, UserID: "user1", Service: "s"}
+
+	_, err := svc.Get(ctx, 1, "user2")
+	assert.ErrorIs(t, err, service.ErrNotFound)
+
+	err = svc.Delete(ctx, 1, "user2")
+	assert.ErrorIs(t, err, service.ErrNotFound)
+
+	err = svc.Update(ctx, sub, "user2")
+	assert.ErrorIs(t, err, service.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateForUser", mock.Anything, mock.Anything, mock.Anything)
+	mockOutbox.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_PauseResume(t *testing.T) {
+	ctx := context.Background()
+	jan := model.MonthYear(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	aug := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	oct := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	sub := &model.Subscription{ID: 1, UserID: "user1", StartDate: jan}
+
+	t.Run("pause publishes event", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOutbox := new(MockOutbox)
+		svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+		pause := &model.SubscriptionPause{ID: 7, SubscriptionID: 1, PausedFrom: model.MonthYear(aug)}
+		mockRepo.On("GetByIDForUser", ctx, int64(1), "user1").Return(sub, nil)
+		mockRepo.On("Pause", ctx, int64(1), aug).Return(pause, nil)
+		mockOutbox.On("Add", ctx, outboxEvent("paused", "")).Return(nil)
+
+		got, err := svc.Pause(ctx, 1, "user1", aug)
+		assert.NoError(t, err)
+		assert.Equal(t, pause, got)
+		mockOutbox.AssertExpectations(t)
+	})
+
+	t.Run("pause before start", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+		mockRepo.On("GetByIDForUser", ctx, int64(1), "user1").Return(sub, nil)
+
+		_, err := svc.Pause(ctx, 1, "user1", time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
+		assert.ErrorIs(t, err, service.ErrInvalidSubscription)
+		mockRepo.AssertNotCalled(t, "Pause", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("resume closes open pause", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOutbox := new(MockOutbox)
+		svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+		resumed := model.MonthYear(oct)
+		mockRepo.On("GetByIDForUser", ctx, int64(1), "user1").Return(sub, nil)
+		mockRepo.On("OpenPause", ctx, int64(1)).Return(&model.SubscriptionPause{ID: 7, PausedFrom: model.MonthYear(aug)}, nil)
+		mockRepo.On("Resume", ctx, int64(7), oct).Return(&model.SubscriptionPause{ID: 7, ResumedFrom: &resumed}, nil)
+		mockOutbox.On("Add", ctx, outboxEvent("resumed", "")).Return(nil)
+
+		_, err := svc.Resume(ctx, 1, "user1", oct)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockOutbox.AssertExpectations(t)
+	})
+
+	t.Run("resume before pause start", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+		mockRepo.On("GetByIDForUser", ctx, int64(1), "user1").Return(sub, nil)
+		mockRepo.On("OpenPause", ctx, int64(1)).Return(&model.SubscriptionPause{ID: 7, PausedFrom: model.MonthYear(oct)}, nil)
+
+		_, err := svc.Resume(ctx, 1, "user1", aug)
+		assert.ErrorIs(t, err, service.ErrInvalidSubscription)
+	})
+
+	t.Run("resume without pause", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+		mockRepo.On("GetByIDForUser", ctx, int64(1), "user1").Return(sub, nil)
+		mockRepo.On("OpenPause", ctx, int64(1)).Return(nil, service.ErrNotPaused)
+
+		_, err := svc.Resume(ctx, 1, "user1", time.Time{})
+		assert.ErrorIs(t, err, service.ErrNotPaused)
+	})
+}
+
 func TestSubscriptionService_List(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockRepo)
 	svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
 
 	list := []model.Subscription{{ID: 1, UserID: "user1", Service: "s"}}
-	mockRepo.On("List", ctx, "user1").Return(list, nil)
+	f := model.SubscriptionFilter{UserID: "user1", Trial: model.TrialActive}
+	// по умолчанию: новые первыми, 20 строк (+1 для признака следующей страницы)
+	p := model.SubscriptionPageRequest{Sort: model.SortCreatedAt, Desc: true, Limit: 21}
+	mockRepo.On("List", ctx, f, p).Return(list, nil)
 
-	result, err := svc.List(ctx, "user1")
+	result, err := svc.List(ctx, model.SubscriptionListQuery{UserID: "user1", Trial: model.TrialActive})
 	assert.NoError(t, err)
-	assert.Equal(t, list, result)
+	assert.Equal(t, &model.SubscriptionPage{Items: list}, result)
+}
+
+func TestSubscriptionService_List_Pages(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+	subs := []model.Subscription{
+		{ID: 7, UserID: "user1", Service: "a", Price: 100},
+		{ID: 3, UserID: "user1", Service: "b", Price: 200},
+		{ID: 5, UserID: "user1", Service: "c", Price: 200},
+	}
+	priceMin := 100
+	activeOn := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	f := model.SubscriptionFilter{UserID: "user1", ActiveOn: &activeOn, PriceMin: &priceMin, Status: model.StatusActive}
+	q := model.SubscriptionListQuery{UserID: "user1", ActiveOn: "07-2025", PriceMin: "100", Status: "active", Sort: "price", Limit: "2"}
+
+	mockRepo.On("List", ctx, f, model.SubscriptionPageRequest{Sort: model.SortPrice, Limit: 3}).Return(subs, nil).Once()
+	first, err := svc.List(ctx, q)
+	require.NoError(t, err)
+	assert.Equal(t, subs[:2], first.Items)
+	require.NotEmpty(t, first.NextCursor)
+
+	// курсор указывает на последнюю строку страницы: цена 200, id 3
+	after := &model.SubscriptionCursor{Sort: model.SortPrice, Value: "200", ID: 3}
+	mockRepo.On("List", ctx, f, model.SubscriptionPageRequest{Sort: model.SortPrice, Limit: 3, After: after}).Return(subs[2:], nil).Once()
+	q.Cursor = first.NextCursor
+	second, err := svc.List(ctx, q)
+	require.NoError(t, err)
+	assert.Equal(t, subs[2:], second.Items)
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestSubscriptionService_List_InvalidQuery(t *testing.T) {
+	priceCursor := model.CursorAfter(model.SortPrice, false, model.Subscription{ID: 1, Price: 100}).Encode()
+	tampered := func(c model.SubscriptionCursor) string { return c.Encode() }
+
+	tests := []struct {
+		name string
+		q    model.SubscriptionListQuery
+	}{
+		{"unknown status", model.SubscriptionListQuery{Status: "trial"}},
+		{"bad active_on", model.SubscriptionListQuery{ActiveOn: "July"}},
+		{"negative price", model.SubscriptionListQuery{PriceMin: "-1"}},
+		{"price range reversed", model.SubscriptionListQuery{PriceMin: "500", PriceMax: "100"}},
+		{"unknown sort", model.SubscriptionListQuery{Sort: "user_id"}},
+		{"unknown order", model.SubscriptionListQuery{Order: "up"}},
+		{"limit too large", model.SubscriptionListQuery{Limit: "101"}},
+		{"zero limit", model.SubscriptionListQuery{Limit: "0"}},
+		{"garbage cursor", model.SubscriptionListQuery{Cursor: "not-a-cursor"}},
+		{"cursor for other sort", model.SubscriptionListQuery{Sort: "price", Order: "desc", Cursor: priceCursor}},
+		{"price cursor with text value", model.SubscriptionListQuery{Sort: "price", Cursor: tampered(model.SubscriptionCursor{Sort: model.SortPrice, Value: "abc", ID: 1})}},
+		{"price cursor out of int range", model.SubscriptionListQuery{Sort: "price", Cursor: tampered(model.SubscriptionCursor{Sort: model.SortPrice, Value: "99999999999", ID: 1})}},
+		{"start_date cursor with bad date", model.SubscriptionListQuery{Sort: "start_date", Cursor: tampered(model.SubscriptionCursor{Sort: model.SortStartDate, Value: "07-2025", ID: 1})}},
+		{"created_at cursor with bad time", model.SubscriptionListQuery{Cursor: tampered(model.SubscriptionCursor{Sort: model.SortCreatedAt, Desc: true, Value: "yesterday", ID: 1})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+			tt.q.UserID = "user1"
+			_, err := svc.List(context.Background(), tt.q)
+			assert.ErrorIs(t, err, service.ErrInvalidListQuery)
+			mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSubscriptionService_Search(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+	matches := []model.SubscriptionMatch{{Subscription: model.Subscription{ID: 1, Service: "Netflix"}, Score: 0.5}}
+	// пробелы по краям отбрасываются, limit 0 — по умолчанию
+	mockRepo.On("Search", ctx, "user1", "netflx", 20).Return(matches, nil)
+	mockRepo.On("SuggestServices", ctx, "yand", 5).Return([]string{"Yandex Plus"}, nil)
+
+	result, err := svc.Search(ctx, "user1", "  netflx ", 0)
+	require.NoError(t, err)
+	assert.Equal(t, matches, result)
+
+	names, err := svc.SuggestServices(ctx, "yand", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Yandex Plus"}, names)
+}
+
+func TestSubscriptionService_Search_InvalidQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		limit int
+	}{
+		{"empty", "   ", 0},
+		{"too long", strings.Repeat("я", 101), 0},
+		{"negative limit", "netflix", -1},
+		{"limit too large", "netflix", 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+			_, err := svc.Search(context.Background(), "user1", tt.query, tt.limit)
+			assert.ErrorIs(t, err, service.ErrInvalidListQuery)
+			_, err = svc.SuggestServices(context.Background(), tt.query, tt.limit)
+			assert.ErrorIs(t, err, service.ErrInvalidListQuery)
+			mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "SuggestServices", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSubscriptionService_NotifyTrialsEnding(t *testing.T) {
+	ctx := context.Background()
+	trialEnd := model.Date(time.Now().UTC().AddDate(0, 0, 2))
+	subs := []model.Subscription{
+		{ID: 1, UserID: "user1", Service: "Netflix", TrialEnd: &trialEnd},
+		{ID: 2, UserID: "user2", Service: "Spotify", TrialEnd: &trialEnd},
+	}
+
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+	mockRepo.On("ListTrialsEnding", ctx, mock.AnythingOfType("time.Time")).Return(subs, nil)
+	mockOutbox.On("Add", ctx, outboxEvent("trial_ending", `"service_name":"Netflix"`)).Return(nil)
+	mockOutbox.On("Add", ctx, outboxEvent("trial_ending", "")).Return(errors.New("connection reset"))
+	mockRepo.On("MarkTrialNotified", ctx, int64(1)).Return(nil)
+
+	sent, err := svc.NotifyTrialsEnding(ctx, 72*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	// неудачная запись события откатывает отметку, следующий запуск повторит подписку
+	mockRepo.AssertNotCalled(t, "MarkTrialNotified", ctx, int64(2))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ExpireEnded(t *testing.T) {
+	ctx := context.Background()
+	end := model.MonthYear(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	subs := []model.Subscription{{ID: 1, UserID: "user1", Service: "Netflix", EndDate: &end}}
+
+	mockRepo := new(MockRepo)
+	mockOutbox := new(MockOutbox)
+	svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+	mockRepo.On("ListExpired", ctx).Return(subs, nil)
+	mockOutbox.On("Add", ctx, outboxEvent("expired", "")).Return(nil)
+	mockRepo.On("MarkExpired", ctx, int64(1)).Return(nil)
+
+	n, err := svc.ExpireEnded(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	mockRepo.AssertExpectations(t)
+	mockOutbox.AssertExpectations(t)
+}
+
+func TestSubscriptionService_NotifyRenewalsDue(t *testing.T) {
+	ctx := context.Background()
+	dueOn := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	due := []model.RenewalDue{{
+		Subscription: model.Subscription{ID: 1, UserID: "user1", Service: "Netflix"},
+		DueOn:        model.Date(dueOn),
+	}}
+
+	t.Run("publishes and marks due date", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOutbox := new(MockOutbox)
+		svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+		mockRepo.On("ListRenewalsDue", ctx).Return(due, nil)
+		mockOutbox.On("Add", ctx, outboxEvent("renewal_due", `"due_on":"2025-08-01"`)).Return(nil)
+		mockRepo.On("MarkRenewalNotified", ctx, int64(1), dueOn).Return(nil)
+
+		n, err := svc.NotifyRenewalsDue(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("outbox failure leaves it for next run", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOutbox := new(MockOutbox)
+		svc := service.NewSubscriptionService(mockRepo, nil, mockOutbox, time.Minute)
+
+		mockRepo.On("ListRenewalsDue", ctx).Return(due, nil)
+		mockOutbox.On("Add", ctx, outboxEvent("renewal_due", "")).Return(errors.New("connection reset"))
+
+		n, err := svc.NotifyRenewalsDue(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		mockRepo.AssertNotCalled(t, "MarkRenewalNotified", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
 func TestSubscriptionService_GetSummary_DBCall(t *testing.T) {
@@ -158,11 +787,152 @@ func TestSubscriptionService_GetSummary_DBCall(t *testing.T) {
 	fromTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	toTime := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
 
-	mockRepo.On("GetSummary", ctx, "user1", "test", fromTime, toTime).Return(100, nil)
+	summary := &model.Summary{
+		Total: 1200,
+		Items: []model.SummaryItem{{SubscriptionID: 1, Service: "test", Price: 100, BillingPeriod: model.BillingMonth, Charges: 12, Cost: 1200}},
+	}
+	f := model.SummaryFilter{UserID: "user1", Service: "test", From: fromTime, To: toTime, Currency: "USD"}
+	mockRepo.On("GetSummary", ctx, f).Return(summary, nil)
 
-	total, err := svc.GetSummary(ctx, "user1", "test", from, to)
+	result, err := svc.GetSummary(ctx, model.SummaryQuery{UserID: "user1", Service: "test", From: from, To: to, Currency: "usd"})
 	assert.NoError(t, err)
-	assert.Equal(t, 100, total)
+	assert.Equal(t, 1200, result.Total)
+	assert.Equal(t, 12, result.Items[0].Charges)
+	assert.Nil(t, result.Breakdown)
+
+	mockRepo.AssertCalled(t, "GetSummary", ctx, f)
+}
+
+func TestSubscriptionService_GetSummary_DefaultToIsCurrentMonth(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
 
-	mockRepo.AssertCalled(t, "GetSummary", ctx, "user1", "test", fromTime, toTime)
+	now := time.Now().UTC()
+	fromTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	toTime := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	f := model.SummaryFilter{UserID: "user1", From: fromTime, To: toTime, Currency: model.DefaultCurrency}
+	mockRepo.On("GetSummary", ctx, f).Return(&model.Summary{}, nil)
+
+	_, err := svc.GetSummary(ctx, model.SummaryQuery{UserID: "user1", From: "01-2025"})
+	assert.NoError(t, err)
+
+	mockRepo.AssertCalled(t, "GetSummary", ctx, f)
+}
+
+// MockRedis кеш без записей; запоминает ключи Get
+type MockRedis struct {
+	keys []string
+}
+
+func (m *MockRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	m.keys = append(m.keys, key)
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (m *MockRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *MockRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (m *MockRedis) Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return redis.NewScanCmdResult(nil, 0, nil)
+}
+
+func TestSubscriptionService_GetSummary_CacheKeyUsesResolvedMonths(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	cache := new(MockRedis)
+	svc := service.NewSubscriptionService(mockRepo, cache, nil, time.Minute)
+	mockRepo.On("GetSummary", ctx, mock.Anything).Return(&model.Summary{}, nil)
+
+	now := time.Now().UTC()
+	_, err := svc.GetSummary(ctx, model.SummaryQuery{UserID: "user1", From: "01-2025"})
+	require.NoError(t, err)
+	_, err = svc.GetSummary(ctx, model.SummaryQuery{UserID: "user1", From: "01-2025", To: now.Format("01-2006")})
+	require.NoError(t, err)
+
+	// пустой to и явный текущий месяц — один и тот же диапазон и один ключ
+	require.Len(t, cache.keys, 2)
+	assert.Equal(t, cache.keys[0], cache.keys[1])
+	assert.Contains(t, cache.keys[0], "2025-01-"+now.Format("2006-01"))
+}
+
+func TestSubscriptionService_GetSummary_GroupBy(t *testing.T) {
+	ctx := context.Background()
+	f := model.SummaryFilter{
+		UserID:   "user1",
+		From:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Currency: model.DefaultCurrency,
+	}
+	jan := model.MonthYear(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	byService := []model.ServiceTotal{{Service: "Netflix", Total: 300}}
+	byServiceMonth := []model.ServiceTotal{{Service: "Netflix", Total: 300, Series: []model.MonthTotal{{Month: jan, Total: 300}}}}
+	byMonth := []model.MonthTotal{{Month: jan, Total: 300}}
+
+	tests := []struct {
+		name      string
+		groupBy   []string
+		mockSetup func(*MockRepo)
+		want      *model.SummaryBreakdown
+		wantErr   bool
+	}{
+		{
+			name:    "by service",
+			groupBy: []string{"service"},
+			mockSetup: func(m *MockRepo) {
+				m.On("SummaryByService", ctx, f).Return(byService, nil)
+			},
+			want: &model.SummaryBreakdown{ByService: byService},
+		},
+		{
+			name:    "by month",
+			groupBy: []string{"month"},
+			mockSetup: func(m *MockRepo) {
+				m.On("SummaryByMonth", ctx, f).Return(byMonth, nil)
+			},
+			want: &model.SummaryBreakdown{ByMonth: byMonth},
+		},
+		{
+			name:    "by service and month in any order",
+			groupBy: []string{"month", "service"},
+			mockSetup: func(m *MockRepo) {
+				m.On("SummaryByServiceMonth", ctx, f).Return(byServiceMonth, nil)
+				m.On("SummaryByMonth", ctx, f).Return(byMonth, nil)
+			},
+			want: &model.SummaryBreakdown{ByService: byServiceMonth, ByMonth: byMonth},
+		},
+		{
+			name:      "unknown group",
+			groupBy:   []string{"user"},
+			mockSetup: func(_ *MockRepo) {},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			if !tt.wantErr {
+				mockRepo.On("GetSummary", ctx, f).Return(&model.Summary{Total: 300}, nil)
+			}
+			tt.mockSetup(mockRepo)
+			svc := service.NewSubscriptionService(mockRepo, nil, nil, time.Minute)
+
+			result, err := svc.GetSummary(ctx, model.SummaryQuery{UserID: "user1", From: "01-2025", To: "03-2025", GroupBy: tt.groupBy})
+			if tt.wantErr {
+				assert.Error(t, err)
+				mockRepo.AssertNotCalled(t, "GetSummary", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result.Breakdown)
+			mockRepo.AssertExpectations(t)
+		})
+	}
 }
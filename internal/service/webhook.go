@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+	"github.com/iokiris/efm-subscription-api/internal/webhook"
+
+	"go.uber.org/zap"
+)
+
+// webhookSecretPrefix префикс сгенерированного секрета подписи
+const webhookSecretPrefix = "whsec_"
+
+// deliveriesLimit сколько последних доставок отдаёт Deliveries
+const deliveriesLimit = 100
+
+var (
+	// ErrWebhookNotFound webhook не найден или принадлежит другому пользователю
+	ErrWebhookNotFound = repo.ErrWebhookNotFound
+	// ErrDeliveryNotFound доставка не найдена у этого webhook
+	ErrDeliveryNotFound = repo.ErrDeliveryNotFound
+	// ErrInvalidWebhook некорректный url или неизвестный тип события
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// WebhookQueue ставит событие в доставку webhooks владельца подписки (см. SubscriptionService.SetWebhooks)
+type WebhookQueue interface {
+	Enqueue(ctx context.Context, userID, eventType, eventID string, payload []byte) (int64, error)
+}
+
+// WebhookServiceInterface интерфейс для сервиса webhooks
+type WebhookServiceInterface interface {
+	Create(ctx context.Context, w *model.Webhook) (string, error)
+	List(ctx context.Context, userID string) ([]model.Webhook, error)
+	Get(ctx context.Context, id int64, userID string) (*model.Webhook, error)
+	Update(ctx context.Context, w *model.Webhook) error
+	Delete(ctx context.Context, id int64, userID string) error
+	Deliveries(ctx context.Context, id int64, userID string) ([]model.WebhookDelivery, error)
+	Attempts(ctx context.Context, id, deliveryID int64, userID string) ([]model.WebhookAttempt, error)
+}
+
+type WebhookService struct {
+	repo repo.WebhookRepoInterface
+}
+
+func NewWebhookService(r repo.WebhookRepoInterface) *WebhookService {
+	return &WebhookService{repo: r}
+}
+
+// Create регистрирует webhook. Пустой Secret генерируется; секрет возвращается только здесь.
+func (s *WebhookService) Create(ctx context.Context, w *model.Webhook) (string, error) {
+	if err := validateWebhook(w); err != nil {
+		return "", err
+	}
+	if w.Secret == "" {
+		secret, err := randomBase64(32)
+		if err != nil {
+			return "", err
+		}
+		w.Secret = webhookSecretPrefix + secret
+	}
+
+	if err := s.repo.Create(ctx, w); err != nil {
+		logger.L.Error("webhook.create.failed", zap.String("user_id", w.UserID), zap.Error(err))
+		return "", err
+	}
+	logger.L.Info("webhook.create.ok", zap.Int64("id", w.ID), zap.String("user_id", w.UserID))
+	return w.Secret, nil
+}
+
+// List возвращает webhooks пользователя (без секретов)
+func (s *WebhookService) List(ctx context.Context, userID string) ([]model.Webhook, error) {
+	hooks, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		logger.L.Error("webhook.list.failed", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int64, userID string) (*model.Webhook, error) {
+	w, err := s.repo.Get(ctx, id, userID)
+	if err != nil && !errors.Is(err, ErrWebhookNotFound) {
+		logger.L.Error("webhook.get.failed", zap.Int64("id", id), zap.Error(err))
+	}
+	return w, err
+}
+
+// Update меняет url, события и включённость. Включение выключенного webhook сбрасывает счётчик неудач.
+func (s *WebhookService) Update(ctx context.Context, w *model.Webhook) error {
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, w); err != nil {
+		if !errors.Is(err, ErrWebhookNotFound) {
+			logger.L.Error("webhook.update.failed", zap.Int64("id", w.ID), zap.Error(err))
+		}
+		return err
+	}
+	logger.L.Info("webhook.update.ok", zap.Int64("id", w.ID), zap.Bool("enabled", w.Enabled))
+	return nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int64, userID string) error {
+	if err := s.repo.Delete(ctx, id, userID); err != nil {
+		if !errors.Is(err, ErrWebhookNotFound) {
+			logger.L.Error("webhook.delete.failed", zap.Int64("id", id), zap.Error(err))
+		}
+		return err
+	}
+	logger.L.Info("webhook.delete.ok", zap.Int64("id", id), zap.String("user_id", userID))
+	return nil
+}
+
+// Deliveries последние доставки webhook пользователя, новые первыми
+func (s *WebhookService) Deliveries(ctx context.Context, id int64, userID string) ([]model.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.ListDeliveries(ctx, id, deliveriesLimit)
+	if err != nil {
+		logger.L.Error("webhook.deliveries.failed", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Attempts попытки доставки deliveryID webhook пользователя
+func (s *WebhookService) Attempts(ctx context.Context, id, deliveryID int64, userID string) ([]model.WebhookAttempt, error) {
+	if _, err := s.Get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, id, deliveryID)
+	if err != nil && !errors.Is(err, ErrDeliveryNotFound) {
+		logger.L.Error("webhook.attempts.failed", zap.Int64("delivery_id", deliveryID), zap.Error(err))
+	}
+	return attempts, err
+}
+
+// validateWebhook проверяет url (абсолютный http/https, не во внутренней сети) и типы событий
+func validateWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if err := webhook.CheckHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: url must point to a public host", ErrInvalidWebhook)
+	}
+
+	known := make([]string, 0, len(events.All()))
+	for _, e := range events.All() {
+		known = append(known, e.Type())
+	}
+	for _, typ := range w.Events {
+		if !slices.Contains(known, typ) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, typ)
+		}
+	}
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	return nil
+}
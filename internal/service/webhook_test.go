@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) Create(ctx context.Context, w *model.Webhook) error {
+	return m.Called(ctx, w).Error(0)
+}
+
+func (m *MockWebhookRepo) Get(ctx context.Context, id int64, userID string) (*model.Webhook, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListByUser(ctx context.Context, userID string) ([]model.Webhook, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) Update(ctx context.Context, w *model.Webhook) error {
+	return m.Called(ctx, w).Error(0)
+}
+
+func (m *MockWebhookRepo) Delete(ctx context.Context, id int64, userID string) error {
+	return m.Called(ctx, id, userID).Error(0)
+}
+
+func (m *MockWebhookRepo) Enqueue(ctx context.Context, userID, eventType, eventID string, payload []byte) (int64, error) {
+	args := m.Called(ctx, userID, eventType, eventID, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.WebhookDispatch, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	return args.Get(0).([]model.WebhookDispatch), args.Error(1)
+}
+
+func (m *MockWebhookRepo) AddAttempt(ctx context.Context, a *model.WebhookAttempt) error {
+	return m.Called(ctx, a).Error(0)
+}
+
+func (m *MockWebhookRepo) FinishAttempt(ctx context.Context, deliveryID int64, status string, nextAttempt time.Time) error {
+	return m.Called(ctx, deliveryID, status, nextAttempt).Error(0)
+}
+
+func (m *MockWebhookRepo) ResetFailures(ctx context.Context, webhookID int64) error {
+	return m.Called(ctx, webhookID).Error(0)
+}
+
+func (m *MockWebhookRepo) AddFailure(ctx context.Context, webhookID int64, disableAfter int) (bool, error) {
+	args := m.Called(ctx, webhookID, disableAfter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, limit)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListAttempts(ctx context.Context, webhookID, deliveryID int64) ([]model.WebhookAttempt, error) {
+	args := m.Called(ctx, webhookID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.WebhookAttempt), args.Error(1)
+}
+
+func TestWebhookService_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    model.Webhook
+		wantErr    error
+		wantSecret string
+	}{
+		{
+			name:    "generated secret, all events",
+			webhook: model.Webhook{UserID: "u1", URL: "https://example.com/hook"},
+		},
+		{
+			name:       "own secret and filter",
+			webhook:    model.Webhook{UserID: "u1", URL: "http://hooks.example.com:9000/hook", Secret: "s3cret", Events: []string{"subscription.deleted.v1"}},
+			wantSecret: "s3cret",
+		},
+		{
+			name:    "relative url",
+			webhook: model.Webhook{UserID: "u1", URL: "/hook"},
+			wantErr: service.ErrInvalidWebhook,
+		},
+		{
+			name:    "unsupported scheme",
+			webhook: model.Webhook{UserID: "u1", URL: "ftp://example.com/hook"},
+			wantErr: service.ErrInvalidWebhook,
+		},
+		{
+			name:    "localhost",
+			webhook: model.Webhook{UserID: "u1", URL: "http://localhost:8080/admin/events/replay"},
+			wantErr: service.ErrInvalidWebhook,
+		},
+		{
+			name:    "cloud metadata",
+			webhook: model.Webhook{UserID: "u1", URL: "http://169.254.169.254/latest/meta-data/"},
+			wantErr: service.ErrInvalidWebhook,
+		},
+		{
+			name:    "private network",
+			webhook: model.Webhook{UserID: "u1", URL: "https://10.0.0.5/hook"},
+			wantErr: service.ErrInvalidWebhook,
+		},
+		{
+			name:    "unknown event",
+			webhook: model.Webhook{UserID: "u1", URL: "https://example.com/hook", Events: []string{"subscription.created"}},
+			wantErr: service.ErrInvalidWebhook,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockRepo := new(MockWebhookRepo)
+			mockRepo.On("Create", ctx, mock.AnythingOfType("*model.Webhook")).Return(nil)
+
+			w := tt.webhook
+			secret, err := service.NewWebhookService(mockRepo).Create(ctx, &w)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, w.Secret, secret)
+			assert.NotNil(t, w.Events)
+			if tt.wantSecret != "" {
+				assert.Equal(t, tt.wantSecret, secret)
+			} else {
+				assert.True(t, strings.HasPrefix(secret, "whsec_"))
+				assert.Greater(t, len(secret), 40)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Attempts_OtherUser(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockWebhookRepo)
+	mockRepo.On("Get", ctx, int64(1), "u2").Return(nil, service.ErrWebhookNotFound)
+
+	_, err := service.NewWebhookService(mockRepo).Attempts(ctx, 1, 10, "u2")
+	assert.ErrorIs(t, err, service.ErrWebhookNotFound)
+	mockRepo.AssertNotCalled(t, "ListAttempts", mock.Anything, mock.Anything, mock.Anything)
+}
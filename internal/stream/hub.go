@@ -0,0 +1,154 @@
+// Package stream раздаёт события подписок открытым соединениям пользователей (SSE) внутри процесса.
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// Event событие потока. ID растёт монотонно в пределах процесса (Last-Event-ID для возобновления).
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte // конверт CloudEvents
+
+	userID string
+}
+
+// Hub рассылает события подписчикам их пользователя и хранит последние history событий
+// всех пользователей для возобновления после переподключения.
+// Подписчик, не успевающий читать (очередь buffer заполнена), отключается:
+// клиент переподключается с Last-Event-ID и получает пропущенное из буфера.
+type Hub struct {
+	history int
+	buffer  int
+
+	mu      sync.Mutex
+	seq     uint64
+	recent  []Event // кольцевой буфер, recent[start] — самое старое событие
+	start   int
+	clients map[string]map[*Client]struct{} // по пользователю
+}
+
+// Client соединение пользователя. Events закрывается при отключении из-за отставания или Close.
+type Client struct {
+	hub    *Hub
+	userID string
+	ch     chan Event
+}
+
+// NewHub создаёт hub с буфером history событий и очередью buffer событий на соединение
+func NewHub(history, buffer int) *Hub {
+	return &Hub{
+		history: max(history, 1),
+		buffer:  max(buffer, 1),
+		clients: make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Notify передаёт событие подписчикам userID. Вызывается после коммита изменения.
+func (h *Hub) Notify(userID string, env *events.Envelope) {
+	if userID == "" {
+		return
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		logger.L.Error("stream.notify.failed", zap.String("type", env.Type), zap.Error(err))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e := Event{ID: h.seq, Type: env.Type, Data: data, userID: userID}
+	h.remember(e)
+
+	for c := range h.clients[userID] {
+		select {
+		case c.ch <- e:
+		default:
+			logger.L.Warn("stream.client.lagged", zap.String("user_id", userID), zap.Uint64("event_id", e.ID))
+			h.remove(c)
+		}
+	}
+}
+
+// Subscribe подключает соединение userID. Если lastID > 0, возвращает события пользователя после него;
+// complete = false, если часть из них уже вытеснена из буфера (или lastID выдан до перезапуска)
+// и клиенту нужно перечитать состояние целиком.
+func (h *Hub) Subscribe(userID string, lastID uint64) (c *Client, backlog []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c = &Client{hub: h, userID: userID, ch: make(chan Event, h.buffer)}
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*Client]struct{})
+	}
+	h.clients[userID][c] = struct{}{}
+
+	if lastID == 0 {
+		return c, nil, true
+	}
+	complete = lastID <= h.seq
+	if len(h.recent) == h.history && h.recent[h.start].ID > lastID+1 {
+		complete = false
+	}
+	for i := range h.recent {
+		e := h.recent[(h.start+i)%len(h.recent)]
+		if e.ID > lastID && e.userID == userID {
+			backlog = append(backlog, e)
+		}
+	}
+	return c, backlog, complete
+}
+
+// Close отключает все соединения (остановка сервера)
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, clients := range h.clients {
+		for c := range clients {
+			h.remove(c)
+		}
+	}
+}
+
+// Events очередь событий соединения
+func (c *Client) Events() <-chan Event {
+	return c.ch
+}
+
+// Close отключает соединение; повторный вызов безопасен
+func (c *Client) Close() {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.hub.remove(c)
+}
+
+// remember добавляет событие в кольцевой буфер; вызывается под h.mu
+func (h *Hub) remember(e Event) {
+	if len(h.recent) < h.history {
+		h.recent = append(h.recent, e)
+		return
+	}
+	h.recent[h.start] = e
+	h.start = (h.start + 1) % h.history
+}
+
+// remove отключает соединение и закрывает его очередь; вызывается под h.mu
+func (h *Hub) remove(c *Client) {
+	clients := h.clients[c.userID]
+	if _, ok := clients[c]; !ok {
+		return
+	}
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.clients, c.userID)
+	}
+	close(c.ch)
+}
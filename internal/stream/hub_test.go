@@ -0,0 +1,113 @@
+package stream_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+func envelope(typ string) *events.Envelope {
+	return &events.Envelope{ID: "e", Type: typ}
+}
+
+func ids(evs []stream.Event) []uint64 {
+	out := make([]uint64, 0, len(evs))
+	for _, e := range evs {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestHub_Notify(t *testing.T) {
+	hub := stream.NewHub(10, 4)
+	c1, _, _ := hub.Subscribe("u1", 0)
+	c2, _, _ := hub.Subscribe("u2", 0)
+
+	hub.Notify("u1", envelope("subscription.created.v1"))
+	hub.Notify("u2", envelope("subscription.deleted.v1"))
+
+	e := <-c1.Events()
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, "subscription.created.v1", e.Type)
+	var env events.Envelope
+	require.NoError(t, json.Unmarshal(e.Data, &env))
+	assert.Equal(t, "e", env.ID)
+	assert.Equal(t, uint64(2), (<-c2.Events()).ID)
+	assert.Empty(t, c1.Events())
+}
+
+func TestHub_Resume(t *testing.T) {
+	tests := []struct {
+		name         string
+		notify       []string // владельцы событий по порядку
+		lastID       uint64
+		wantBacklog  []uint64
+		wantComplete bool
+	}{
+		{"new connection", []string{"u1", "u1"}, 0, nil, true},
+		{"missed events", []string{"u1", "u2", "u1", "u1"}, 1, []uint64{3, 4}, true},
+		{"up to date", []string{"u1", "u1"}, 2, nil, true},
+		{"evicted from buffer", []string{"u1", "u1", "u1", "u1", "u1"}, 1, []uint64{3, 4, 5}, false},
+		{"id from previous process", []string{"u1"}, 7, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := stream.NewHub(3, 4)
+			for _, userID := range tt.notify {
+				hub.Notify(userID, envelope("subscription.updated.v1"))
+			}
+
+			c, backlog, complete := hub.Subscribe("u1", tt.lastID)
+			defer c.Close()
+			assert.Equal(t, tt.wantBacklog, nilIfEmpty(ids(backlog)))
+			assert.Equal(t, tt.wantComplete, complete)
+		})
+	}
+}
+
+func TestHub_SlowClientDropped(t *testing.T) {
+	hub := stream.NewHub(10, 2)
+	slow, _, _ := hub.Subscribe("u1", 0)
+	fast, _, _ := hub.Subscribe("u1", 0)
+
+	for range 3 {
+		hub.Notify("u1", envelope("subscription.updated.v1"))
+		<-fast.Events()
+	}
+
+	// очередь медленного соединения закрыта после двух непрочитанных событий
+	var got []uint64
+	for e := range slow.Events() {
+		got = append(got, e.ID)
+	}
+	assert.Equal(t, []uint64{1, 2}, got)
+
+	// переподключение досылает пропущенное
+	_, backlog, complete := hub.Subscribe("u1", 2)
+	require.True(t, complete)
+	assert.Equal(t, []uint64{3}, ids(backlog))
+
+	slow.Close() // уже отключён: повторное закрытие безопасно
+	hub.Close()
+	_, ok := <-fast.Events()
+	assert.False(t, ok)
+}
+
+func nilIfEmpty(v []uint64) []uint64 {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress адрес получателя во внутренней сети (loopback, private, link-local и т.п.)
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace 100.64.0.0/10 (CGNAT): не входит в IsPrivate, но снаружи недоступен
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicAddr можно ли отправлять webhook на этот адрес: только публичный unicast
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// CheckHost отклоняет localhost и IP-литералы внутренней сети. Имена проверяются только
+// при подключении (NewClient): адрес, в который они разрешаются, может измениться.
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !PublicAddr(addr) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// NewClient HTTP-клиент для доставки webhooks. Адрес проверяется после разрешения имени,
+// непосредственно перед подключением (в том числе при редиректах), поэтому DNS rebinding
+// не позволяет обратиться к внутренним сервисам. Прокси из окружения не используется:
+// иначе проверялся бы адрес прокси, а не получателя.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !PublicAddr(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, ap.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
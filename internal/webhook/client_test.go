@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, PublicAddr(netip.MustParseAddr(tt.addr)), tt.addr)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	assert.NoError(t, CheckHost("example.com"))
+	assert.NoError(t, CheckHost("93.184.216.34"))
+	assert.ErrorIs(t, CheckHost("localhost"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckHost("api.LOCALHOST."), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckHost("127.0.0.1"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckHost("::1"), ErrForbiddenAddress)
+}
+
+func TestNewClient_RejectsInternalAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	// имя проходит CheckHost, но адрес проверяется при подключении
+	_, err := NewClient(time.Second).Get(srv.URL)
+	assert.True(t, errors.Is(err, ErrForbiddenAddress), "got %v", err)
+}
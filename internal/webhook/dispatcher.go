@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/events"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+)
+
+// Границы задержки повторной доставки
+const (
+	minBackoff = 10 * time.Second
+	maxBackoff = time.Hour
+)
+
+// leaseMargin запас аренды доставки сверх таймаута запроса
+const leaseMargin = 30 * time.Second
+
+// errorBodyLimit сколько байт ответа с ошибкой сохраняется в попытке
+const errorBodyLimit = 512
+
+// Dispatcher отправляет доставки webhooks: POST конверта CloudEvents с подписью HMAC-SHA256.
+// Ответ 2xx — доставлено; иначе повтор с экспоненциальной задержкой, после maxAttempts — failed.
+// После disableAfter неудачных попыток подряд webhook выключается.
+// Реплики могут работать одновременно: ClaimDue пропускает доставки, взятые другими.
+type Dispatcher struct {
+	repo         repo.WebhookRepoInterface
+	client       *http.Client
+	interval     time.Duration
+	batch        int
+	maxAttempts  int
+	disableAfter int
+}
+
+func NewDispatcher(r repo.WebhookRepoInterface, client *http.Client, interval time.Duration, batch, maxAttempts, disableAfter int) *Dispatcher {
+	return &Dispatcher{
+		repo:         r,
+		client:       client,
+		interval:     interval,
+		batch:        batch,
+		maxAttempts:  maxAttempts,
+		disableAfter: disableAfter,
+	}
+}
+
+// Run блокируется до отмены ctx. Полный батч выбирается сразу же снова, иначе — через interval.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	logger.L.Info("webhook.dispatcher.started", zap.Duration("interval", d.interval), zap.Int("batch", d.batch))
+	for {
+		select {
+		case <-ctx.Done():
+			logger.L.Info("webhook.dispatcher.stopped")
+			return
+		case <-timer.C:
+		}
+
+		n, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.L.Error("webhook.dispatcher.failed", zap.Error(err))
+		}
+		if err == nil && n == d.batch {
+			timer.Reset(0)
+		} else {
+			timer.Reset(d.interval)
+		}
+	}
+}
+
+// DispatchOnce отправляет один батч параллельно и возвращает число взятых доставок
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	due, err := d.repo.ClaimDue(ctx, d.batch, time.Now().Add(d.client.Timeout+leaseMargin))
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, w)
+		}()
+	}
+	wg.Wait()
+	return len(due), nil
+}
+
+// deliver выполняет одну попытку и записывает её результат.
+// Попытка, прерванная остановкой сервиса, не записывается: после аренды доставку повторят.
+func (d *Dispatcher) deliver(ctx context.Context, w model.WebhookDispatch) {
+	delivery := w.Delivery
+	attempt := model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: delivery.Attempts + 1}
+
+	start := time.Now()
+	code, sendErr := d.send(ctx, w)
+	if ctx.Err() != nil {
+		return
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if code != 0 {
+		attempt.StatusCode = &code
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	if err := d.repo.AddAttempt(ctx, &attempt); err != nil {
+		logger.L.Error("webhook.attempt.save_failed", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+
+	if sendErr == nil {
+		if err := d.repo.FinishAttempt(ctx, delivery.ID, model.DeliveryDelivered, time.Now()); err != nil {
+			logger.L.Error("webhook.delivery.save_failed", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+		}
+		if err := d.repo.ResetFailures(ctx, delivery.WebhookID); err != nil {
+			logger.L.Error("webhook.failures.reset_failed", zap.Int64("webhook_id", delivery.WebhookID), zap.Error(err))
+		}
+		return
+	}
+
+	status, next := model.DeliveryPending, time.Now().Add(backoff(attempt.Attempt))
+	if attempt.Attempt >= d.maxAttempts {
+		status = model.DeliveryFailed
+	}
+	if err := d.repo.FinishAttempt(ctx, delivery.ID, status, next); err != nil {
+		logger.L.Error("webhook.delivery.save_failed", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+	logger.L.Warn("webhook.delivery.failed",
+		zap.Int64("delivery_id", delivery.ID),
+		zap.Int64("webhook_id", delivery.WebhookID),
+		zap.Int("attempt", attempt.Attempt),
+		zap.String("status", status),
+		zap.Time("next_attempt", next),
+		zap.Error(sendErr))
+
+	disabled, err := d.repo.AddFailure(ctx, delivery.WebhookID, d.disableAfter)
+	if err != nil {
+		logger.L.Error("webhook.failures.save_failed", zap.Int64("webhook_id", delivery.WebhookID), zap.Error(err))
+	}
+	if disabled {
+		logger.L.Warn("webhook.disabled", zap.Int64("webhook_id", delivery.WebhookID), zap.Int("failures", d.disableAfter))
+	}
+}
+
+// send отправляет доставку; возвращает код ответа (0 — ответа нет) и ошибку для любого ответа, кроме 2xx
+func (d *Dispatcher) send(ctx context.Context, w model.WebhookDispatch) (int, error) {
+	delivery := w.Delivery
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", events.ContentType)
+	req.Header.Set("User-Agent", "efm-subscription-api-webhooks")
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(delivery.WebhookID, 10))
+	req.Header.Set("X-Event-Id", delivery.EventID)
+	req.Header.Set("X-Event-Type", delivery.EventType)
+
+	now := time.Now()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return resp.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+	return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+}
+
+// backoff задержка после неудачной попытки attempt (с 1): minBackoff, удваиваясь, но не больше maxBackoff
+func backoff(attempt int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
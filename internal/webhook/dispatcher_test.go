@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+// mockWebhookRepo реализует только методы, нужные Dispatcher
+type mockWebhookRepo struct {
+	mock.Mock
+	mu       sync.Mutex
+	attempts []model.WebhookAttempt
+}
+
+func (m *mockWebhookRepo) Create(context.Context, *model.Webhook) error { panic("unexpected") }
+func (m *mockWebhookRepo) Get(context.Context, int64, string) (*model.Webhook, error) {
+	panic("unexpected")
+}
+func (m *mockWebhookRepo) ListByUser(context.Context, string) ([]model.Webhook, error) {
+	panic("unexpected")
+}
+func (m *mockWebhookRepo) Update(context.Context, *model.Webhook) error { panic("unexpected") }
+func (m *mockWebhookRepo) Delete(context.Context, int64, string) error  { panic("unexpected") }
+func (m *mockWebhookRepo) Enqueue(context.Context, string, string, string, []byte) (int64, error) {
+	panic("unexpected")
+}
+func (m *mockWebhookRepo) ListDeliveries(context.Context, int64, int) ([]model.WebhookDelivery, error) {
+	panic("unexpected")
+}
+func (m *mockWebhookRepo) ListAttempts(context.Context, int64, int64) ([]model.WebhookAttempt, error) {
+	panic("unexpected")
+}
+
+func (m *mockWebhookRepo) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]model.WebhookDispatch, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	return args.Get(0).([]model.WebhookDispatch), args.Error(1)
+}
+
+func (m *mockWebhookRepo) AddAttempt(_ context.Context, a *model.WebhookAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, *a)
+	return nil
+}
+
+func (m *mockWebhookRepo) FinishAttempt(ctx context.Context, deliveryID int64, status string, nextAttempt time.Time) error {
+	return m.Called(ctx, deliveryID, status, nextAttempt).Error(0)
+}
+
+func (m *mockWebhookRepo) ResetFailures(ctx context.Context, webhookID int64) error {
+	return m.Called(ctx, webhookID).Error(0)
+}
+
+func (m *mockWebhookRepo) AddFailure(ctx context.Context, webhookID int64, disableAfter int) (bool, error) {
+	args := m.Called(ctx, webhookID, disableAfter)
+	return args.Bool(0), args.Error(1)
+}
+
+// after матчер времени next, наступающего через d после start (с точностью до секунды)
+func after(start time.Time, d time.Duration) interface{} {
+	return mock.MatchedBy(func(next time.Time) bool {
+		delta := next.Sub(start)
+		return delta >= d && delta < d+time.Second
+	})
+}
+
+func TestDispatcher_DispatchOnce(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"specversion":"1.0","id":"e1","type":"subscription.created.v1","data":{}}`)
+
+	tests := []struct {
+		name         string
+		status       int
+		attempts     int // попыток до этой
+		disabled     bool
+		wantStatus   string
+		wantBackoff  time.Duration
+		wantDisabled bool
+	}{
+		{name: "delivered", status: http.StatusNoContent, wantStatus: model.DeliveryDelivered},
+		{name: "server error is retried", status: http.StatusInternalServerError, attempts: 2, wantStatus: model.DeliveryPending, wantBackoff: 40 * time.Second},
+		{name: "last attempt fails delivery and disables webhook", status: http.StatusGone, attempts: 4, disabled: true, wantStatus: model.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var gotBody []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("receiver says no\n"))
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			repo := new(mockWebhookRepo)
+			delivery := model.WebhookDelivery{ID: 10, WebhookID: 3, EventID: "e1", EventType: "subscription.created.v1", Payload: payload, Attempts: tt.attempts}
+			repo.On("ClaimDue", ctx, 5, mock.Anything).Return([]model.WebhookDispatch{{Delivery: delivery, URL: srv.URL, Secret: secret}}, nil)
+
+			start := time.Now()
+			if tt.wantStatus == model.DeliveryDelivered {
+				repo.On("FinishAttempt", ctx, int64(10), model.DeliveryDelivered, mock.Anything).Return(nil)
+				repo.On("ResetFailures", ctx, int64(3)).Return(nil)
+			} else {
+				repo.On("FinishAttempt", ctx, int64(10), tt.wantStatus, mock.Anything).Return(nil)
+				repo.On("AddFailure", ctx, int64(3), 20).Return(tt.disabled, nil)
+			}
+
+			n, err := NewDispatcher(repo, srv.Client(), time.Second, 5, 5, 20).DispatchOnce(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			repo.AssertExpectations(t)
+			if tt.wantBackoff > 0 {
+				repo.AssertCalled(t, "FinishAttempt", ctx, int64(10), tt.wantStatus, after(start, tt.wantBackoff))
+			}
+
+			// получатель проверяет подпись общим секретом
+			require.NotNil(t, got)
+			assert.Equal(t, payload, gotBody)
+			assert.Equal(t, "application/cloudevents+json", got.Header.Get("Content-Type"))
+			assert.Equal(t, "3", got.Header.Get("X-Webhook-Id"))
+			assert.Equal(t, "e1", got.Header.Get("X-Event-Id"))
+			assert.NoError(t, Verify(secret, got.Header.Get(HeaderSignature), got.Header.Get(HeaderTimestamp), gotBody, time.Minute))
+
+			require.Len(t, repo.attempts, 1)
+			a := repo.attempts[0]
+			assert.Equal(t, tt.attempts+1, a.Attempt)
+			assert.Equal(t, tt.status, *a.StatusCode)
+			if tt.wantStatus == model.DeliveryDelivered {
+				assert.Empty(t, a.Error)
+			} else {
+				assert.Equal(t, "unexpected status "+strconv.Itoa(tt.status)+": receiver says no", a.Error)
+			}
+		})
+	}
+}
+
+func TestDispatcher_ConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	ctx := context.Background()
+	repo := new(mockWebhookRepo)
+	delivery := model.WebhookDelivery{ID: 10, WebhookID: 3, Payload: []byte(`{}`)}
+	repo.On("ClaimDue", ctx, 5, mock.Anything).Return([]model.WebhookDispatch{{Delivery: delivery, URL: srv.URL, Secret: "s"}}, nil)
+	repo.On("FinishAttempt", ctx, int64(10), model.DeliveryPending, mock.Anything).Return(nil)
+	repo.On("AddFailure", ctx, int64(3), 20).Return(false, nil)
+
+	_, err := NewDispatcher(repo, srv.Client(), time.Second, 5, 5, 20).DispatchOnce(ctx)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	require.Len(t, repo.attempts, 1)
+	assert.Nil(t, repo.attempts[0].StatusCode)
+	assert.NotEmpty(t, repo.attempts[0].Error)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := Sign("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		want      error
+	}{
+		{"valid", "secret", sig, ts, body, nil},
+		{"wrong secret", "other", sig, ts, body, ErrInvalidSignature},
+		{"tampered body", "secret", sig, ts, []byte(`{"id":"e2"}`), ErrInvalidSignature},
+		{"tampered timestamp", "secret", sig, strconv.FormatInt(now.Unix()+1, 10), body, ErrInvalidSignature},
+		{"missing prefix", "secret", sig[len("sha256="):], ts, body, ErrInvalidSignature},
+		{"stale", "secret", Sign("secret", now.Add(-time.Hour), body), strconv.FormatInt(now.Add(-time.Hour).Unix(), 10), body, ErrSignatureExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, Verify(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute), tt.want)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{5, 160 * time.Second},
+		{9, 2560 * time.Second},
+		{10, maxBackoff},
+		{100, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoff(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
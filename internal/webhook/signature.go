@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки подписи запроса
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Signature-Timestamp"
+)
+
+// signaturePrefix префикс значения X-Signature (алгоритм)
+const signaturePrefix = "sha256="
+
+var (
+	// ErrInvalidSignature подпись не совпала
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired метка времени вне допустимого окна (защита от повтора запроса)
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// Sign подпись тела запроса: "sha256=" + hex(HMAC-SHA256(secret, "<unix timestamp>.<body>")).
+// Метка времени передаётся в X-Signature-Timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись на стороне получателя: signature и timestamp — значения заголовков
+// X-Signature и X-Signature-Timestamp. Запросы старше tolerance отклоняются; 0 — без проверки времени.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	ts := time.Unix(unix, 0)
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if age := time.Since(ts); age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package worker
+
+import "context"
+
+// Lifecycle обрабатывает истечение подписок и наступление списаний
+type Lifecycle interface {
+	ExpireEnded(ctx context.Context) (int, error)
+	NotifyRenewalsDue(ctx context.Context) (int, error)
+}
+
+// ExpiryJob публикует expired для подписок с прошедшим end_date
+type ExpiryJob struct {
+	svc Lifecycle
+}
+
+func NewExpiryJob(svc Lifecycle) *ExpiryJob {
+	return &ExpiryJob{svc: svc}
+}
+
+func (j *ExpiryJob) Name() string { return "expiry" }
+
+func (j *ExpiryJob) Run(ctx context.Context) (int, error) {
+	return j.svc.ExpireEnded(ctx)
+}
+
+// RenewalDueJob публикует renewal_due при наступлении очередного списания
+type RenewalDueJob struct {
+	svc Lifecycle
+}
+
+func NewRenewalDueJob(svc Lifecycle) *RenewalDueJob {
+	return &RenewalDueJob{svc: svc}
+}
+
+func (j *RenewalDueJob) Name() string { return "renewal_due" }
+
+func (j *RenewalDueJob) Run(ctx context.Context) (int, error) {
+	return j.svc.NotifyRenewalsDue(ctx)
+}
@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+)
+
+// OutboxCleanupJob удаляет из outbox события, опубликованные раньше retention
+type OutboxCleanupJob struct {
+	repo      repo.OutboxRepoInterface
+	retention time.Duration
+}
+
+func NewOutboxCleanupJob(r repo.OutboxRepoInterface, retention time.Duration) *OutboxCleanupJob {
+	return &OutboxCleanupJob{repo: r, retention: retention}
+}
+
+func (j *OutboxCleanupJob) Name() string { return "outbox_cleanup" }
+
+func (j *OutboxCleanupJob) Run(ctx context.Context) (int, error) {
+	n, err := j.repo.DeleteSent(ctx, time.Now().Add(-j.retention))
+	return int(n), err
+}
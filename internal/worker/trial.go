@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// TrialNotifier рассылает уведомления об окончании пробного периода
+type TrialNotifier interface {
+	NotifyTrialsEnding(ctx context.Context, notice time.Duration) (int, error)
+}
+
+// TrialEndingJob публикует trial_ending за notice до окончания пробного периода
+type TrialEndingJob struct {
+	svc    TrialNotifier
+	notice time.Duration
+}
+
+func NewTrialEndingJob(svc TrialNotifier, notice time.Duration) *TrialEndingJob {
+	return &TrialEndingJob{svc: svc, notice: notice}
+}
+
+func (j *TrialEndingJob) Name() string { return "trial_ending" }
+
+func (j *TrialEndingJob) Run(ctx context.Context) (int, error) {
+	return j.svc.NotifyTrialsEnding(ctx, j.notice)
+}
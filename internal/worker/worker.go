@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/infra"
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/model"
+	"github.com/iokiris/efm-subscription-api/internal/repo"
+
+	"go.uber.org/zap"
+)
+
+// Job фоновая задача, которую Runner запускает каждый тик.
+// Run должен быть идемпотентным и возвращать число обработанных подписок.
+type Job interface {
+	Name() string
+	Run(ctx context.Context) (int, error)
+}
+
+// Runner последовательно запускает задачи сразу после старта и затем каждые tick.
+// Каждый запуск записывается в job_runs (если задан runs) и в метрики (если заданы).
+type Runner struct {
+	tick    time.Duration
+	runs    repo.JobRunRepoInterface
+	jobs    []Job
+	metrics *infra.Metrics
+}
+
+func NewRunner(tick time.Duration, runs repo.JobRunRepoInterface, jobs ...Job) *Runner {
+	return &Runner{tick: tick, runs: runs, jobs: jobs}
+}
+
+// SetMetrics устанавливает метрики для воркера
+func (r *Runner) SetMetrics(metrics *infra.Metrics) {
+	r.metrics = metrics
+}
+
+// Run блокируется до отмены ctx. Ошибка задачи логируется и не останавливает остальные.
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.tick)
+	defer ticker.Stop()
+
+	logger.L.Info("worker.started", zap.Duration("tick", r.tick), zap.Int("jobs", len(r.jobs)))
+	for {
+		r.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.L.Info("worker.stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) runOnce(ctx context.Context) {
+	for _, job := range r.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		r.runJob(ctx, job)
+	}
+}
+
+func (r *Runner) runJob(ctx context.Context, job Job) {
+	name := job.Name()
+
+	var runID int64
+	if r.runs != nil {
+		id, err := r.runs.Start(ctx, name)
+		if err != nil {
+			// журнал вторичен: задача всё равно выполняется
+			logger.L.Warn("worker.run.record_failed", zap.String("job", name), zap.Error(err))
+		}
+		runID = id
+	}
+
+	start := time.Now()
+	processed, err := job.Run(ctx)
+	took := time.Since(start)
+
+	if r.runs != nil && runID != 0 {
+		if ferr := r.runs.Finish(ctx, runID, processed, err); ferr != nil {
+			logger.L.Warn("worker.run.record_failed", zap.String("job", name), zap.Error(ferr))
+		}
+	}
+
+	status := model.JobOK
+	if err != nil {
+		status = model.JobFailed
+		logger.L.Error("worker.job.failed", zap.String("job", name), zap.Error(err))
+	} else {
+		logger.L.Debug("worker.job.ok", zap.String("job", name), zap.Int("processed", processed), zap.Duration("took", took))
+	}
+
+	if r.metrics != nil {
+		r.metrics.WorkerJobRunsTotal.WithLabelValues(name, status).Inc()
+		r.metrics.WorkerJobDuration.WithLabelValues(name).Observe(took.Seconds())
+		r.metrics.WorkerItemsProcessed.WithLabelValues(name).Add(float64(processed))
+	}
+}
@@ -0,0 +1,138 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iokiris/efm-subscription-api/internal/logger"
+	"github.com/iokiris/efm-subscription-api/internal/worker"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.L = zap.NewNop()
+}
+
+type countingJob struct {
+	name      string
+	processed int
+	err       error
+	calls     atomic.Int32
+}
+
+func (j *countingJob) Name() string { return j.name }
+
+func (j *countingJob) Run(_ context.Context) (int, error) {
+	j.calls.Add(1)
+	return j.processed, j.err
+}
+
+type finishedRun struct {
+	job       string
+	processed int
+	err       error
+}
+
+// memRuns журнал запусков в памяти
+type memRuns struct {
+	mu       sync.Mutex
+	jobs     map[int64]string
+	finished []finishedRun
+}
+
+func (m *memRuns) Start(_ context.Context, job string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs == nil {
+		m.jobs = map[int64]string{}
+	}
+	id := int64(len(m.jobs) + 1)
+	m.jobs[id] = job
+	return id, nil
+}
+
+func (m *memRuns) Finish(_ context.Context, id int64, processed int, runErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished = append(m.finished, finishedRun{job: m.jobs[id], processed: processed, err: runErr})
+	return nil
+}
+
+func (m *memRuns) snapshot() []finishedRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]finishedRun(nil), m.finished...)
+}
+
+func TestRunner_Run(t *testing.T) {
+	boom := errors.New("boom")
+	failing := &countingJob{name: "failing", err: boom}
+	ok := &countingJob{name: "ok", processed: 3}
+	runs := &memRuns{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		worker.NewRunner(10*time.Millisecond, runs, failing, ok).Run(ctx)
+		close(done)
+	}()
+
+	// первый запуск сразу, затем по тику; ошибка одной задачи не мешает другой
+	assert.Eventually(t, func() bool { return ok.calls.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, failing.calls.Load(), int32(2))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runner did not stop after cancel")
+	}
+
+	finished := runs.snapshot()
+	assert.GreaterOrEqual(t, len(finished), 4)
+	assert.Equal(t, finishedRun{job: "failing", err: boom}, finished[0])
+	assert.Equal(t, finishedRun{job: "ok", processed: 3}, finished[1])
+}
+
+type stubService struct {
+	notice time.Duration
+}
+
+func (s *stubService) NotifyTrialsEnding(_ context.Context, notice time.Duration) (int, error) {
+	s.notice = notice
+	return 1, nil
+}
+
+func (s *stubService) ExpireEnded(_ context.Context) (int, error) { return 2, nil }
+
+func (s *stubService) NotifyRenewalsDue(_ context.Context) (int, error) { return 3, nil }
+
+func TestJobs(t *testing.T) {
+	svc := &stubService{}
+
+	tests := []struct {
+		job       worker.Job
+		name      string
+		processed int
+	}{
+		{job: worker.NewTrialEndingJob(svc, 72*time.Hour), name: "trial_ending", processed: 1},
+		{job: worker.NewExpiryJob(svc), name: "expiry", processed: 2},
+		{job: worker.NewRenewalDueJob(svc), name: "renewal_due", processed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.job.Name())
+			n, err := tt.job.Run(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.processed, n)
+		})
+	}
+	assert.Equal(t, 72*time.Hour, svc.notice)
+}
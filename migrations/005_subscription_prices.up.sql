@@ -0,0 +1,13 @@
+-- цена действует с effective_from до следующей записи той же подписки
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+-- история для существующих подписок: текущая цена с начала подписки
+INSERT INTO subscription_prices (subscription_id, effective_from, price)
+SELECT id, start_date, price FROM subscriptions
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscription_pauses_open;
+DROP INDEX IF EXISTS idx_subscription_pauses_subscription_id;
+DROP TABLE IF EXISTS subscription_pauses;
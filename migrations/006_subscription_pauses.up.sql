@@ -0,0 +1,14 @@
+-- месяцы с paused_from до resumed_from (не включая) не оплачиваются; resumed_from IS NULL — пауза не завершена
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    resumed_from DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    CHECK (resumed_from IS NULL OR resumed_from >= paused_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_pauses_subscription_id ON subscription_pauses(subscription_id);
+-- не больше одной незавершённой паузы на подписку
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_pauses_open
+    ON subscription_pauses(subscription_id) WHERE resumed_from IS NULL;
@@ -0,0 +1,7 @@
+-- trial_end — первый платный день; trial_notified_at — когда отправлено событие trial_ending
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS trial_end DATE,
+    ADD COLUMN IF NOT EXISTS trial_notified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_trial_end
+    ON subscriptions(trial_end) WHERE trial_notified_at IS NULL;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_subscriptions_end_date_not_expired;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS renewal_notified_on,
+    DROP COLUMN IF EXISTS expired_at;
+
+DROP INDEX IF EXISTS idx_job_runs_job_started_at;
+DROP TABLE IF EXISTS job_runs;
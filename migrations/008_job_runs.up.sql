@@ -0,0 +1,20 @@
+-- журнал запусков фоновых задач
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'ok', 'failed')),
+    processed INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started_at ON job_runs(job, started_at DESC);
+
+-- expired_at — когда отправлено событие expired; renewal_notified_on — дата списания, о которой отправлено renewal_due
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS expired_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS renewal_notified_on DATE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_end_date_not_expired
+    ON subscriptions(end_date) WHERE expired_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_event_log_user_id;
+DROP INDEX IF EXISTS idx_event_log_occurred_at;
+DROP TABLE IF EXISTS event_log;
@@ -0,0 +1,16 @@
+-- журнал всех событий подписок: источник для повторной публикации (replay).
+-- В отличие от outbox записи не удаляются после отправки.
+CREATE TABLE IF NOT EXISTS event_log (
+    id BIGSERIAL PRIMARY KEY,
+    event_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    exchange TEXT NOT NULL,
+    routing_key TEXT NOT NULL,
+    user_id UUID,
+    subject TEXT NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_log_occurred_at ON event_log(occurred_at, id);
+CREATE INDEX IF NOT EXISTS idx_event_log_user_id ON event_log(user_id, id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_service_name;
+DROP INDEX IF EXISTS idx_subscriptions_user_start_date;
+DROP INDEX IF EXISTS idx_subscriptions_user_price;
+DROP INDEX IF EXISTS idx_subscriptions_user_created_at;
@@ -0,0 +1,6 @@
+-- keyset-пагинация списка подписок пользователя: (user_id, поле сортировки, id) для каждой сортировки.
+-- Обратный обход индекса обслуживает сортировку по убыванию.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_created_at ON subscriptions(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_price ON subscriptions(user_id, price, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_start_date ON subscriptions(user_id, start_date, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service_name ON subscriptions(user_id, service_name, id);
@@ -0,0 +1,5 @@
+-- нечёткий поиск по имени сервиса: операторы % и <% (pg_trgm) используют GIN индекс триграмм
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_trgm
+    ON subscriptions USING GIN (service_name gin_trgm_ops);